@@ -297,57 +297,41 @@ func createCar(id, make, model string, year int, color string) {
 }
 
 func updateCar(id, make, model string, year int, color string) {
-	// First get the existing car
 	url := fmt.Sprintf("%s/cars/%s", baseURL, id)
-	resp, err := http.Get(url)
-	if err != nil {
-		log.Fatalf("Error fetching car: %v", err)
-	}
 
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		fmt.Printf("Error: %s\n", string(body))
-		resp.Body.Close()
-		os.Exit(1)
-	}
-
-	var existingCar Car
-	if err := json.NewDecoder(resp.Body).Decode(&existingCar); err != nil {
-		log.Fatalf("Error parsing response: %v", err)
-	}
-	resp.Body.Close()
+	// Only send the fields that were provided
+	patch := map[string]interface{}{}
 
-	// Update fields if provided
 	if make != "" {
-		existingCar.Make = make
+		patch["make"] = make
 	}
 
 	if model != "" {
-		existingCar.Model = model
+		patch["model"] = model
 	}
 
 	if year > 0 {
-		existingCar.Year = year
+		patch["year"] = year
 	}
 
 	if color != "" {
-		existingCar.Color = color
+		patch["color"] = color
 	}
 
 	// Send update request
-	payload, err := json.Marshal(existingCar)
+	payload, err := json.Marshal(patch)
 	if err != nil {
 		log.Fatalf("Error creating payload: %v", err)
 	}
 
-	req, err := http.NewRequest(http.MethodPut, url, strings.NewReader(string(payload)))
+	req, err := http.NewRequest(http.MethodPatch, url, strings.NewReader(string(payload)))
 	if err != nil {
 		log.Fatalf("Error creating request: %v", err)
 	}
 	req.Header.Set("Content-Type", "application/json")
 
 	client := &http.Client{}
-	resp, err = client.Do(req)
+	resp, err := client.Do(req)
 	if err != nil {
 		log.Fatalf("Error updating car: %v", err)
 	}
@@ -1,7 +1,6 @@
 package main
 
 import (
-	"flag"
 	"fmt"
 	"log"
 	"net/http"
@@ -10,45 +9,91 @@ import (
 
 	"github.com/joshbarros/golang-carflow-api/internal/cache"
 	"github.com/joshbarros/golang-carflow-api/internal/car"
+	"github.com/joshbarros/golang-carflow-api/internal/config"
 	"github.com/joshbarros/golang-carflow-api/internal/health"
+	"github.com/joshbarros/golang-carflow-api/internal/jobs"
 	"github.com/joshbarros/golang-carflow-api/internal/metrics"
 	"github.com/joshbarros/golang-carflow-api/internal/middleware"
-)
-
-var (
-	// Global cache instance
-	globalCache *cache.Cache
+	"github.com/joshbarros/golang-carflow-api/internal/openapi"
+	"github.com/joshbarros/golang-carflow-api/internal/tenant"
 )
 
 func main() {
-	// Parse command-line flags
-	port := flag.Int("port", 8080, "Port to listen on")
-	rateLimit := flag.Int("rate-limit", 100, "Rate limit in requests per second")
-	rateBurst := flag.Int("rate-burst", 20, "Maximum burst size for rate limiting")
-	flag.Parse()
-
 	// Configure logger
 	log.SetOutput(os.Stdout)
 	log.SetFlags(log.Ldate | log.Ltime | log.Lmicroseconds | log.Lshortfile)
 	log.Println("Starting CarFlow API...")
 
-	// Initialize cache
-	globalCache = cache.New(5 * time.Minute) // Cleanup every 5 minutes
+	// Load and validate configuration
+	cfg, err := config.Load(os.Args[1:])
+	if err != nil {
+		log.Fatalf("Invalid configuration: %v", err)
+	}
+	cfg.Log()
+
+	// Cache cars looked up by ID, cleaning up expired entries every CACHE_TTL
+	// and evicting the least recently used beyond CACHE_MAX_ITEMS
+	carCache := cache.New(cfg.CacheTTL, cfg.CacheMaxItems)
 
 	// Create the metrics tracker
-	metricsTracker := metrics.NewMetrics()
+	metricsTracker := metrics.NewMetricsWithWindows(cfg.MetricsResponseTimesSize, cfg.MetricsLastRequestsSize)
+	metricsTracker.SetCache(carCache)
 	metricsHandler := metrics.NewHandler(metricsTracker)
 
+	// Select how IDs are assigned to cars created without one
+	idGenerator, err := car.NewIDGenerator(cfg.CarIDStrategy, cfg.CarIDPrefix)
+	if err != nil {
+		log.Fatalf("Invalid car ID strategy: %v", err)
+	}
+
+	// Select how car values are estimated
+	valuator, err := car.NewValuator(cfg.ValuationProvider, cfg.ValuationAPIURL)
+	if err != nil {
+		log.Fatalf("Invalid valuation provider: %v", err)
+	}
+
+	serviceOpts := []car.Option{
+		car.WithIDGenerator(idGenerator),
+		car.WithYearBounds(cfg.CarYearMin, cfg.CarYearMax),
+		car.WithValuator(valuator),
+	}
+	if cfg.CacheTTL > 0 {
+		serviceOpts = append(serviceOpts, car.WithCache(carCache, cfg.CacheTTL))
+		if cfg.CacheMode == config.CacheModeWriteThrough {
+			serviceOpts = append(serviceOpts, car.WithWriteThrough())
+		}
+	}
+
+	// Tenants listed in CAR_ID_SEQUENCE_TENANTS get their own CAR-0001 style sequence
+	sequenceGenerator := car.NewSequenceGenerator(cfg.CarIDPrefix)
+	for _, tenantID := range cfg.CarIDSequenceTenants {
+		serviceOpts = append(serviceOpts, car.WithTenantIDGenerator(tenantID, sequenceGenerator))
+	}
+
 	// Create the car repository and service
 	carRepo := car.NewInMemoryRepository()
-	carService := car.NewService(carRepo)
-	carHandler := car.NewHandler(carService)
+	carService := car.NewService(carRepo, serviceOpts...)
+
+	// Track asynchronous batch jobs
+	jobStore := jobs.NewStore(jobs.DefaultRetention)
+	jobsHandler := jobs.NewHandler(jobStore)
+
+	carHandler := car.NewHandler(carService,
+		car.WithValidationStatus(cfg.ValidationErrorStatus),
+		car.WithAdminCheck(func(r *http.Request) bool {
+			return middleware.HasAdminToken(r, cfg.AdminToken)
+		}),
+		car.WithMaxBatchSize(cfg.MaxBatchSize),
+		car.WithJobStore(jobStore),
+	)
 
 	// Create the health check handler
 	healthHandler := health.NewHandler()
 
 	// Create rate limiter
-	rateLimiter := middleware.NewRateLimiter(*rateLimit, *rateBurst, 10*time.Minute)
+	rateLimiter := middleware.NewRateLimiter(cfg.RateLimit, cfg.RateBurst, 10*time.Minute)
+	rateLimiter.SetSoftThreshold(cfg.RateSoftThreshold)
+	rateLimiter.SetUnit(cfg.RateLimitUnit)
 
 	// Add some sample cars for testing
 	seedData(carService)
@@ -56,24 +101,59 @@ func main() {
 	// Create the HTTP server
 	mux := http.NewServeMux()
 
-	// Register routes
-	carHandler.RegisterRoutes(mux)
-	healthHandler.RegisterRoutes(mux)
-	metricsHandler.RegisterRoutes(mux)
-
-	// Add API docs endpoint
-	mux.HandleFunc("GET /api-docs", func(w http.ResponseWriter, r *http.Request) {
-		http.ServeFile(w, r, "docs/openapi.json")
+	// Register routes through a router that records them for the OpenAPI spec
+	router := openapi.NewRouter(mux)
+	carHandler.RegisterRoutes(router)
+	healthHandler.RegisterRoutes(router)
+	metricsHandler.RegisterRoutes(router)
+	jobsHandler.RegisterRoutes(router)
+
+	// Expose the caller's rate-limit state
+	router.HandleFunc("GET /me/rate-limit", middleware.RateLimitStatusHandler(rateLimiter))
+
+	// Expose internal sizes to admins for capacity monitoring
+	router.Handle("GET /admin/internals", middleware.RequireAdminToken(cfg.AdminToken)(
+		health.InternalsHandler(map[string]health.Sizer{
+			"cache_entries":        carCache,
+			"rate_limiter_clients": rateLimiter,
+			"jobs":                 jobStore,
+		}),
+	))
+
+	// Serve the OpenAPI spec generated from the registered routes.
+	// /api-docs is kept for existing clients.
+	spec := openapi.NewSpec("CarFlow API", "1.0.0", router)
+	car.Describe(spec)
+	router.Handle("GET /openapi.json", spec)
+	router.Handle("GET /api-docs", spec)
+
+	// TENANT_HEADER_MODE decides who may name a tenant other than the default
+	tenantMiddleware := tenant.ModeMiddleware(cfg.TenantHeaderMode, func(r *http.Request) bool {
+		return middleware.HasAdminToken(r, cfg.AdminToken)
 	})
 
+	// ServeMux's own 404 and 405 responses are plain text; give them the
+	// same JSON error body as every other error
+	var app http.Handler = tenantMiddleware(middleware.PlainErrorsMiddleware(mux))
+
+	// Chaos testing injects delays and failures on request; the
+	// configuration refuses to enable it in production
+	var corsHeaders []string
+	if cfg.ChaosEnabled {
+		app = middleware.ChaosMiddleware(cfg.ChaosMaxDelay)(app)
+		corsHeaders = middleware.ChaosHeaders
+	}
+
 	// Create a chain of middlewares
-	handler := middleware.CORSMiddleware(
-		middleware.RateLimitMiddleware(rateLimiter)(
-			middleware.ETagMiddleware(
-				metrics.Middleware(metricsTracker)(
-					middleware.LoggingMiddleware(
-						middleware.RecoveryMiddleware(
-							mux,
+	handler := middleware.CORSWithOrigins(cfg.CORSAllowedOrigins, corsHeaders...)(
+		middleware.URLLengthMiddleware(cfg.MaxURLLength, cfg.MaxQueryParamLength)(
+			middleware.RateLimitMiddleware(rateLimiter)(
+				middleware.ETagMiddleware(
+					metrics.Middleware(metricsTracker)(
+						middleware.LoggingMiddleware(
+							middleware.RecoveryMiddleware(
+								app,
+							),
 						),
 					),
 				),
@@ -82,7 +162,7 @@ func main() {
 	)
 
 	// Start the server
-	addr := fmt.Sprintf(":%d", *port)
+	addr := fmt.Sprintf(":%d", cfg.Port)
 	server := &http.Server{
 		Addr:         addr,
 		Handler:      handler,
@@ -106,6 +186,7 @@ func seedData(service *car.Service) {
 	}
 
 	for _, c := range sampleCars {
+		c.TenantID = tenant.DefaultID
 		_, err := service.CreateCar(c)
 		if err != nil {
 			log.Printf("Error seeding car data: %v", err)
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestCSRFProtect_Post(t *testing.T) {
+	tests := []struct {
+		name    string
+		cookie  string
+		form    string
+		status  int
+		handled bool
+	}{
+		{name: "No cookie", form: "valid-token", status: http.StatusForbidden},
+		{name: "Mismatched token", cookie: "valid-token", form: "other-token", status: http.StatusForbidden},
+		{name: "Missing form token", cookie: "valid-token", status: http.StatusForbidden},
+		{name: "Matching token", cookie: "valid-token", form: "valid-token", status: http.StatusOK, handled: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handled := false
+			handler := csrfProtect(cookieOptions{SameSite: http.SameSiteStrictMode}, func(w http.ResponseWriter, r *http.Request) {
+				handled = true
+				if got := csrfToken(r); got != tt.cookie {
+					t.Errorf("csrfToken() = %q, want %q", got, tt.cookie)
+				}
+			})
+
+			form := url.Values{}
+			if tt.form != "" {
+				form.Set(csrfFieldName, tt.form)
+			}
+			req := httptest.NewRequest(http.MethodPost, "/cars/new", strings.NewReader(form.Encode()))
+			req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			if tt.cookie != "" {
+				req.AddCookie(&http.Cookie{Name: csrfCookieName, Value: tt.cookie})
+			}
+			rec := httptest.NewRecorder()
+
+			handler(rec, req)
+
+			if rec.Code != tt.status {
+				t.Errorf("status = %d, want %d", rec.Code, tt.status)
+			}
+			if handled != tt.handled {
+				t.Errorf("handler ran = %v, want %v", handled, tt.handled)
+			}
+		})
+	}
+}
+
+func TestCSRFProtect_GetSetsCookie(t *testing.T) {
+	tests := []struct {
+		name    string
+		cookies cookieOptions
+	}{
+		{name: "Strict", cookies: cookieOptions{SameSite: http.SameSiteStrictMode}},
+		{name: "Secure strict", cookies: cookieOptions{Secure: true, SameSite: http.SameSiteStrictMode}},
+		{name: "Lax", cookies: cookieOptions{SameSite: http.SameSiteLaxMode}},
+		{name: "Secure none", cookies: cookieOptions{Secure: true, SameSite: http.SameSiteNoneMode}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var token string
+			handler := csrfProtect(tt.cookies, func(w http.ResponseWriter, r *http.Request) {
+				token = csrfToken(r)
+			})
+
+			rec := httptest.NewRecorder()
+			handler(rec, httptest.NewRequest(http.MethodGet, "/cars/new", nil))
+
+			cookies := rec.Result().Cookies()
+			if len(cookies) != 1 {
+				t.Fatalf("got %d cookies, want 1", len(cookies))
+			}
+			cookie := cookies[0]
+			if cookie.Name != csrfCookieName || cookie.Value == "" || cookie.Value != token {
+				t.Errorf("cookie %s=%q, want %s matching the token %q", cookie.Name, cookie.Value, csrfCookieName, token)
+			}
+			if !cookie.HttpOnly {
+				t.Error("cookie HttpOnly = false, want true")
+			}
+			if cookie.SameSite != tt.cookies.SameSite {
+				t.Errorf("cookie SameSite = %v, want %v", cookie.SameSite, tt.cookies.SameSite)
+			}
+			if cookie.Secure != tt.cookies.Secure {
+				t.Errorf("cookie Secure = %v, want %v", cookie.Secure, tt.cookies.Secure)
+			}
+		})
+	}
+}
+
+func TestCSRFProtect_GetKeepsExistingCookie(t *testing.T) {
+	handler := csrfProtect(cookieOptions{SameSite: http.SameSiteStrictMode}, func(w http.ResponseWriter, r *http.Request) {})
+
+	req := httptest.NewRequest(http.MethodGet, "/cars/new", nil)
+	req.AddCookie(&http.Cookie{Name: csrfCookieName, Value: "valid-token"})
+	rec := httptest.NewRecorder()
+
+	handler(rec, req)
+
+	if cookies := rec.Result().Cookies(); len(cookies) != 0 {
+		t.Errorf("got %d cookies, want the existing one kept", len(cookies))
+	}
+}
+
+func TestParseSameSite(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    http.SameSite
+		wantErr bool
+	}{
+		{value: "strict", want: http.SameSiteStrictMode},
+		{value: "Lax", want: http.SameSiteLaxMode},
+		{value: "none", want: http.SameSiteNoneMode},
+		{value: "", wantErr: true},
+		{value: "default", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := parseSameSite(tt.value)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parseSameSite(%q) error = %v, wantErr %v", tt.value, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("parseSameSite(%q) = %v, want %v", tt.value, got, tt.want)
+		}
+	}
+}
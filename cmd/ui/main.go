@@ -55,6 +55,7 @@ type PageData struct {
 	FilterYear  int
 	SortField   string
 	SortOrder   string
+	CSRFToken   string
 }
 
 // Define template functions
@@ -77,8 +78,20 @@ var templateFuncs = template.FuncMap{
 func main() {
 	// Parse command line arguments
 	port := flag.Int("port", 3000, "Port to serve the UI on")
+	secureCookies := flag.Bool("secure-cookies", false, "Only send cookies over HTTPS (enable when served behind TLS)")
+	sameSite := flag.String("same-site", "strict", "SameSite attribute of cookies: strict, lax or none (none requires -secure-cookies)")
 	flag.Parse()
 
+	sameSiteMode, err := parseSameSite(*sameSite)
+	if err != nil {
+		log.Fatal(err)
+	}
+	cookies := cookieOptions{Secure: *secureCookies, SameSite: sameSiteMode}
+	// Browsers reject SameSite=None cookies that aren't Secure
+	if cookies.SameSite == http.SameSiteNoneMode && !cookies.Secure {
+		log.Fatal("same-site none requires -secure-cookies")
+	}
+
 	// Set up templates
 	templateDir := "cmd/ui/templates"
 	templates := template.Must(template.New("").Funcs(templateFuncs).ParseGlob(filepath.Join(templateDir, "*.html")))
@@ -94,18 +107,18 @@ func main() {
 	http.HandleFunc("/cars", func(w http.ResponseWriter, r *http.Request) {
 		handleListCars(w, r, templates)
 	})
-	http.HandleFunc("/cars/new", func(w http.ResponseWriter, r *http.Request) {
+	http.HandleFunc("/cars/new", csrfProtect(cookies, func(w http.ResponseWriter, r *http.Request) {
 		handleNewCar(w, r, templates)
-	})
+	}))
 	http.HandleFunc("/cars/view/", func(w http.ResponseWriter, r *http.Request) {
 		handleViewCar(w, r, templates)
 	})
-	http.HandleFunc("/cars/edit/", func(w http.ResponseWriter, r *http.Request) {
+	http.HandleFunc("/cars/edit/", csrfProtect(cookies, func(w http.ResponseWriter, r *http.Request) {
 		handleEditCar(w, r, templates)
-	})
-	http.HandleFunc("/cars/delete/", func(w http.ResponseWriter, r *http.Request) {
+	}))
+	http.HandleFunc("/cars/delete/", csrfProtect(cookies, func(w http.ResponseWriter, r *http.Request) {
 		handleDeleteCar(w, r, templates)
-	})
+	}))
 
 	// Start the server
 	addr := fmt.Sprintf(":%d", *port)
@@ -246,7 +259,8 @@ func handleViewCar(w http.ResponseWriter, r *http.Request, templates *template.T
 // handleNewCar handles creating a new car
 func handleNewCar(w http.ResponseWriter, r *http.Request, templates *template.Template) {
 	data := PageData{
-		Title: "CarFlow - New Car",
+		Title:     "CarFlow - New Car",
+		CSRFToken: csrfToken(r),
 	}
 
 	if r.Method == http.MethodPost {
@@ -329,8 +343,9 @@ func handleEditCar(w http.ResponseWriter, r *http.Request, templates *template.T
 		// Validate form values
 		if make == "" || model == "" || yearStr == "" || color == "" {
 			data := PageData{
-				Title: "CarFlow - Edit Car",
-				Error: "All fields are required",
+				Title:     "CarFlow - Edit Car",
+				CSRFToken: csrfToken(r),
+				Error:     "All fields are required",
 			}
 			templates.ExecuteTemplate(w, "edit.html", data)
 			return
@@ -340,8 +355,9 @@ func handleEditCar(w http.ResponseWriter, r *http.Request, templates *template.T
 		year, err := strconv.Atoi(yearStr)
 		if err != nil || year <= 0 {
 			data := PageData{
-				Title: "CarFlow - Edit Car",
-				Error: "Year must be a valid number",
+				Title:     "CarFlow - Edit Car",
+				CSRFToken: csrfToken(r),
+				Error:     "Year must be a valid number",
 			}
 			templates.ExecuteTemplate(w, "edit.html", data)
 			return
@@ -358,8 +374,9 @@ func handleEditCar(w http.ResponseWriter, r *http.Request, templates *template.T
 
 		if err := updateCar(car); err != nil {
 			data := PageData{
-				Title: "CarFlow - Edit Car",
-				Error: fmt.Sprintf("Error updating car: %v", err),
+				Title:     "CarFlow - Edit Car",
+				CSRFToken: csrfToken(r),
+				Error:     fmt.Sprintf("Error updating car: %v", err),
 			}
 			templates.ExecuteTemplate(w, "edit.html", data)
 			return
@@ -384,8 +401,9 @@ func handleEditCar(w http.ResponseWriter, r *http.Request, templates *template.T
 	}
 
 	data := PageData{
-		Title: fmt.Sprintf("CarFlow - Edit %s %s", car.Make, car.Model),
-		Car:   car,
+		Title:     fmt.Sprintf("CarFlow - Edit %s %s", car.Make, car.Model),
+		CSRFToken: csrfToken(r),
+		Car:       car,
 	}
 
 	if err := templates.ExecuteTemplate(w, "edit.html", data); err != nil {
@@ -434,8 +452,9 @@ func handleDeleteCar(w http.ResponseWriter, r *http.Request, templates *template
 	}
 
 	data := PageData{
-		Title: fmt.Sprintf("CarFlow - Delete %s %s", car.Make, car.Model),
-		Car:   car,
+		Title:     fmt.Sprintf("CarFlow - Delete %s %s", car.Make, car.Model),
+		CSRFToken: csrfToken(r),
+		Car:       car,
 	}
 
 	if err := templates.ExecuteTemplate(w, "delete.html", data); err != nil {
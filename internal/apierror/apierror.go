@@ -0,0 +1,100 @@
+// Package apierror defines the machine-readable codes included in every
+// JSON error response, so clients can branch on them instead of matching
+// messages.
+package apierror
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// Code identifies the kind of error. Codes are stable; messages may change.
+type Code string
+
+// Error codes
+const (
+	BadRequest           Code = "bad_request"
+	ValidationFailed     Code = "validation_failed"
+	InvalidTenant        Code = "invalid_tenant"
+	Unauthorized         Code = "unauthorized"
+	Forbidden            Code = "forbidden"
+	NotFound             Code = "not_found"
+	CarNotFound          Code = "car_not_found"
+	JobNotFound          Code = "job_not_found"
+	MethodNotAllowed     Code = "method_not_allowed"
+	NotAcceptable        Code = "not_acceptable"
+	Conflict             Code = "conflict"
+	PreconditionFailed   Code = "precondition_failed"
+	PayloadTooLarge      Code = "payload_too_large"
+	URITooLong           Code = "uri_too_long"
+	UnsupportedMediaType Code = "unsupported_media_type"
+	RateLimited          Code = "rate_limited"
+	InternalError        Code = "internal_error"
+	UpstreamUnavailable  Code = "upstream_unavailable"
+	ServiceUnavailable   Code = "service_unavailable"
+)
+
+// Codes lists every error code
+var Codes = []Code{
+	BadRequest, ValidationFailed, InvalidTenant, Unauthorized, Forbidden,
+	NotFound, CarNotFound, JobNotFound, MethodNotAllowed, NotAcceptable, Conflict,
+	PreconditionFailed, PayloadTooLarge, URITooLong, UnsupportedMediaType,
+	RateLimited, InternalError, UpstreamUnavailable, ServiceUnavailable,
+}
+
+// statusCodes maps HTTP statuses to their generic code
+var statusCodes = map[int]Code{
+	http.StatusBadRequest:            BadRequest,
+	http.StatusUnauthorized:          Unauthorized,
+	http.StatusForbidden:             Forbidden,
+	http.StatusNotFound:              NotFound,
+	http.StatusMethodNotAllowed:      MethodNotAllowed,
+	http.StatusNotAcceptable:         NotAcceptable,
+	http.StatusConflict:              Conflict,
+	http.StatusPreconditionFailed:    PreconditionFailed,
+	http.StatusRequestEntityTooLarge: PayloadTooLarge,
+	http.StatusRequestURITooLong:     URITooLong,
+	http.StatusUnsupportedMediaType:  UnsupportedMediaType,
+	http.StatusUnprocessableEntity:   ValidationFailed,
+	http.StatusTooManyRequests:       RateLimited,
+	http.StatusBadGateway:            UpstreamUnavailable,
+	http.StatusServiceUnavailable:    ServiceUnavailable,
+}
+
+// ForStatus returns the generic code for an HTTP status. Unknown 4xx
+// statuses map to bad_request and everything else to internal_error.
+func ForStatus(status int) Code {
+	if code, ok := statusCodes[status]; ok {
+		return code
+	}
+	if status >= 400 && status < 500 {
+		return BadRequest
+	}
+	return InternalError
+}
+
+// Body is the JSON body of every error response. Field names the request
+// field at fault, when there is one.
+type Body struct {
+	Error string `json:"error"`
+	Code  Code   `json:"code"`
+	Field string `json:"field,omitempty"`
+}
+
+// Write sends a JSON error response. An empty code uses the generic code
+// for the status.
+func Write(w http.ResponseWriter, status int, code Code, message string) {
+	WriteBody(w, status, Body{Error: message, Code: code})
+}
+
+// WriteBody sends body as a JSON error response, filling in an empty code
+// from the status
+func WriteBody(w http.ResponseWriter, status int, body Body) {
+	if body.Code == "" {
+		body.Code = ForStatus(status)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
@@ -0,0 +1,59 @@
+package apierror
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestForStatus(t *testing.T) {
+	tests := []struct {
+		status int
+		want   Code
+	}{
+		{http.StatusBadRequest, BadRequest},
+		{http.StatusNotFound, NotFound},
+		{http.StatusUnprocessableEntity, ValidationFailed},
+		{http.StatusTooManyRequests, RateLimited},
+		{http.StatusTeapot, BadRequest},
+		{http.StatusInternalServerError, InternalError},
+		{http.StatusGatewayTimeout, InternalError},
+	}
+
+	for _, tt := range tests {
+		if got := ForStatus(tt.status); got != tt.want {
+			t.Errorf("ForStatus(%d) = %q, want %q", tt.status, got, tt.want)
+		}
+	}
+}
+
+func TestWrite(t *testing.T) {
+	tests := []struct {
+		name     string
+		status   int
+		code     Code
+		wantCode Code
+	}{
+		{"explicit code", http.StatusNotFound, CarNotFound, CarNotFound},
+		{"code from status", http.StatusNotFound, "", NotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			Write(rec, tt.status, tt.code, "Nope")
+
+			var body Body
+			if err := json.NewDecoder(rec.Body).Decode(&body); err != nil {
+				t.Fatalf("decoding body: %v", err)
+			}
+			if rec.Code != tt.status || rec.Header().Get("Content-Type") != "application/json" {
+				t.Errorf("response = %d %s, want %d application/json", rec.Code, rec.Header().Get("Content-Type"), tt.status)
+			}
+			if body.Error != "Nope" || body.Code != tt.wantCode {
+				t.Errorf("body = %+v, want error Nope with code %q", body, tt.wantCode)
+			}
+		})
+	}
+}
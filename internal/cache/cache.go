@@ -1,7 +1,9 @@
 package cache
 
 import (
+	"container/list"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -19,16 +21,52 @@ func (item Item) Expired() bool {
 	return time.Now().UnixNano() > item.Expiration
 }
 
-// Cache is a simple in-memory cache
+// Cache is a simple in-memory cache. When it holds more than its maximum
+// number of items, the least recently used item is evicted.
 type Cache struct {
-	items map[string]Item
-	mu    sync.RWMutex
+	items    map[string]*list.Element
+	order    *list.List // front is most recently used
+	maxItems int
+	mu       sync.RWMutex
+
+	hits      atomic.Uint64
+	misses    atomic.Uint64
+	evictions atomic.Uint64
+
+	// keyLocks serializes GetOrSet computations and Lock holders per key
+	keyLocks   map[string]*keyLock
+	keyLocksMu sync.Mutex
+}
+
+// keyLock is a mutex shared by the callers computing the same key
+type keyLock struct {
+	mu   sync.Mutex
+	refs int
 }
 
-// New creates a new cache instance with cleanup
-func New(cleanupInterval time.Duration) *Cache {
+// Stats reports how effective the cache is. Evictions count items dropped
+// for space or because they expired.
+type Stats struct {
+	Hits      uint64 `json:"hits"`
+	Misses    uint64 `json:"misses"`
+	Evictions uint64 `json:"evictions"`
+	Items     int    `json:"items"`
+}
+
+// entry is a cached item together with its key, stored in the LRU list
+type entry struct {
+	key  string
+	item Item
+}
+
+// New creates a new cache instance with cleanup. maxItems bounds the number
+// of items held; 0 means unlimited.
+func New(cleanupInterval time.Duration, maxItems int) *Cache {
 	cache := &Cache{
-		items: make(map[string]Item),
+		items:    make(map[string]*list.Element),
+		order:    list.New(),
+		maxItems: maxItems,
+		keyLocks: make(map[string]*keyLock),
 	}
 
 	// Start cleanup goroutine if needed
@@ -49,36 +87,127 @@ func (c *Cache) Set(key string, value interface{}, duration time.Duration) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
-	c.items[key] = Item{
+	item := Item{
 		Value:      value,
 		Expiration: expiration,
 	}
+
+	if elem, found := c.items[key]; found {
+		elem.Value.(*entry).item = item
+		c.order.MoveToFront(elem)
+		return
+	}
+
+	c.items[key] = c.order.PushFront(&entry{key: key, item: item})
+	if c.maxItems > 0 && c.order.Len() > c.maxItems {
+		c.removeElement(c.order.Back())
+		c.evictions.Add(1)
+	}
 }
 
-// Get retrieves an item from the cache
+// Get retrieves an item from the cache, marking it as recently used
 func (c *Cache) Get(key string) (interface{}, bool) {
-	c.mu.RLock()
-	defer c.mu.RUnlock()
+	value, found := c.lookup(key)
+	if found {
+		c.hits.Add(1)
+	} else {
+		c.misses.Add(1)
+	}
+	return value, found
+}
 
-	item, found := c.items[key]
+// lookup is Get without counting the hit or miss
+func (c *Cache) lookup(key string) (interface{}, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	elem, found := c.items[key]
 	if !found {
 		return nil, false
 	}
 
 	// Check if the item has expired
+	item := elem.Value.(*entry).item
 	if item.Expired() {
 		return nil, false
 	}
 
+	c.order.MoveToFront(elem)
 	return item.Value, true
 }
 
+// GetOrSet returns the cached value for key, calling fn to compute and
+// cache it for ttl on a miss. Concurrent callers missing the same key wait
+// for a single call to fn instead of each computing the value. Errors from
+// fn are returned and not cached.
+func (c *Cache) GetOrSet(key string, ttl time.Duration, fn func() (interface{}, error)) (interface{}, error) {
+	if value, found := c.Get(key); found {
+		return value, nil
+	}
+
+	lock := c.lockKey(key)
+	defer c.unlockKey(key, lock)
+
+	// Another caller may have filled the key while we waited for the lock.
+	// The miss was already counted above.
+	if value, found := c.lookup(key); found {
+		return value, nil
+	}
+
+	value, err := fn()
+	if err != nil {
+		return nil, err
+	}
+
+	c.Set(key, value, ttl)
+	return value, nil
+}
+
+// Lock takes the per-key lock that GetOrSet holds while computing key and
+// returns the function releasing it. Writers hold it while changing the
+// source of a cached value, so a concurrent GetOrSet can't cache the old
+// value after they invalidate it.
+func (c *Cache) Lock(key string) (unlock func()) {
+	lock := c.lockKey(key)
+	return func() { c.unlockKey(key, lock) }
+}
+
+// lockKey acquires the per-key lock for key
+func (c *Cache) lockKey(key string) *keyLock {
+	c.keyLocksMu.Lock()
+	lock, found := c.keyLocks[key]
+	if !found {
+		lock = &keyLock{}
+		c.keyLocks[key] = lock
+	}
+	lock.refs++
+	c.keyLocksMu.Unlock()
+
+	lock.mu.Lock()
+	return lock
+}
+
+// unlockKey releases the per-key lock, dropping it once no caller uses it
+func (c *Cache) unlockKey(key string, lock *keyLock) {
+	lock.mu.Unlock()
+
+	c.keyLocksMu.Lock()
+	defer c.keyLocksMu.Unlock()
+
+	lock.refs--
+	if lock.refs == 0 {
+		delete(c.keyLocks, key)
+	}
+}
+
 // Delete removes an item from the cache
 func (c *Cache) Delete(key string) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
-	delete(c.items, key)
+	if elem, found := c.items[key]; found {
+		c.removeElement(elem)
+	}
 }
 
 // Clear removes all items from the cache
@@ -86,7 +215,28 @@ func (c *Cache) Clear() {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
-	c.items = make(map[string]Item)
+	c.items = make(map[string]*list.Element)
+	c.order.Init()
+}
+
+// Len returns the number of items in the cache, including expired items
+// that haven't been cleaned up yet
+func (c *Cache) Len() int {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return len(c.items)
+}
+
+// Stats returns the cache's hit, miss and eviction counts since it was
+// created, along with its current size
+func (c *Cache) Stats() Stats {
+	return Stats{
+		Hits:      c.hits.Load(),
+		Misses:    c.misses.Load(),
+		Evictions: c.evictions.Load(),
+		Items:     c.Len(),
+	}
 }
 
 // cleanup removes expired items from the cache
@@ -94,13 +244,21 @@ func (c *Cache) cleanup() {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
-	for key, item := range c.items {
-		if item.Expired() {
-			delete(c.items, key)
+	for _, elem := range c.items {
+		if elem.Value.(*entry).item.Expired() {
+			c.removeElement(elem)
+			c.evictions.Add(1)
 		}
 	}
 }
 
+// removeElement drops an item from the map and the LRU list. The caller
+// must hold the write lock.
+func (c *Cache) removeElement(elem *list.Element) {
+	c.order.Remove(elem)
+	delete(c.items, elem.Value.(*entry).key)
+}
+
 // cleanupLoop runs cleanup at the specified interval
 func (c *Cache) cleanupLoop(interval time.Duration) {
 	ticker := time.NewTicker(interval)
@@ -0,0 +1,164 @@
+package cache
+
+import (
+	"errors"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestCache_GetOrSetComputesOnce(t *testing.T) {
+	const callers = 50
+
+	c := New(0, 0)
+
+	var calls atomic.Int32
+	start := make(chan struct{})
+	fn := func() (interface{}, error) {
+		calls.Add(1)
+		// Keep the computation running while the other callers arrive
+		time.Sleep(20 * time.Millisecond)
+		return "value", nil
+	}
+
+	var wg sync.WaitGroup
+	results := make([]interface{}, callers)
+	for i := 0; i < callers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			<-start
+			value, err := c.GetOrSet("key", time.Minute, fn)
+			if err != nil {
+				t.Errorf("GetOrSet() error = %v", err)
+			}
+			results[i] = value
+		}(i)
+	}
+	close(start)
+	wg.Wait()
+
+	if got := calls.Load(); got != 1 {
+		t.Errorf("fn ran %d times, want 1", got)
+	}
+	for i, value := range results {
+		if value != "value" {
+			t.Errorf("caller %d got %v, want %q", i, value, "value")
+		}
+	}
+	if len(c.keyLocks) != 0 {
+		t.Errorf("keyLocks holds %d locks after all callers finished, want 0", len(c.keyLocks))
+	}
+}
+
+func TestCache_GetOrSetDoesNotCacheErrors(t *testing.T) {
+	c := New(0, 0)
+	errBoom := errors.New("boom")
+
+	if _, err := c.GetOrSet("key", time.Minute, func() (interface{}, error) { return nil, errBoom }); !errors.Is(err, errBoom) {
+		t.Fatalf("GetOrSet() error = %v, want %v", err, errBoom)
+	}
+
+	value, err := c.GetOrSet("key", time.Minute, func() (interface{}, error) { return 42, nil })
+	if err != nil || value != 42 {
+		t.Errorf("GetOrSet() after error = %v, %v, want 42", value, err)
+	}
+
+	// The cached value is returned without calling fn
+	value, _ = c.GetOrSet("key", time.Minute, func() (interface{}, error) { return 0, nil })
+	if value != 42 {
+		t.Errorf("GetOrSet() on hit = %v, want cached 42", value)
+	}
+}
+
+func TestCache_EvictsLeastRecentlyUsed(t *testing.T) {
+	const maxItems = 3
+
+	c := New(0, maxItems)
+	for _, key := range []string{"a", "b", "c"} {
+		c.Set(key, key, 0)
+	}
+
+	// Reading "a" makes "b" the least recently used
+	c.Get("a")
+	c.Set("d", "d", 0)
+
+	if _, found := c.Get("b"); found {
+		t.Errorf("Get(%q) found, want it evicted", "b")
+	}
+	for _, key := range []string{"a", "c", "d"} {
+		if _, found := c.Get(key); !found {
+			t.Errorf("Get(%q) not found, want it kept", key)
+		}
+	}
+	if c.Len() != maxItems {
+		t.Errorf("Len() = %d, want %d", c.Len(), maxItems)
+	}
+}
+
+func TestCache_EvictsOldestWithoutReads(t *testing.T) {
+	const maxItems = 100
+
+	c := New(0, maxItems)
+	for i := 0; i <= maxItems; i++ {
+		c.Set(strconv.Itoa(i), i, 0)
+	}
+
+	if _, found := c.Get("0"); found {
+		t.Errorf("oldest key still cached after inserting %d keys", maxItems+1)
+	}
+	if _, found := c.Get("1"); !found {
+		t.Errorf("second oldest key was evicted")
+	}
+	if c.Len() != maxItems {
+		t.Errorf("Len() = %d, want %d", c.Len(), maxItems)
+	}
+}
+
+func TestCache_UpdateRefreshesRecency(t *testing.T) {
+	c := New(0, 2)
+	c.Set("a", 1, 0)
+	c.Set("b", 2, 0)
+	c.Set("a", 3, 0)
+	c.Set("c", 4, 0)
+
+	if value, found := c.Get("a"); !found || value != 3 {
+		t.Errorf("Get(%q) = %v, %v, want updated 3", "a", value, found)
+	}
+	if _, found := c.Get("b"); found {
+		t.Errorf("Get(%q) found, want it evicted", "b")
+	}
+}
+
+func TestCache_Stats(t *testing.T) {
+	c := New(0, 2)
+	c.Set("a", 1, 0)
+	c.Set("b", 2, 0)
+	c.Set("expired", 3, time.Nanosecond)
+	time.Sleep(time.Millisecond)
+
+	c.Get("b")       // hit
+	c.Get("missing") // miss
+	c.Get("expired") // miss
+
+	// "a" was evicted for space when "expired" was added; cleanup then
+	// drops the expired item
+	c.cleanup()
+
+	want := Stats{Hits: 1, Misses: 2, Evictions: 2, Items: 1}
+	if got := c.Stats(); got != want {
+		t.Errorf("Stats() = %+v, want %+v", got, want)
+	}
+}
+
+func TestCache_GetOrSetCountsOneMiss(t *testing.T) {
+	c := New(0, 0)
+	c.GetOrSet("key", 0, func() (interface{}, error) { return 1, nil })
+	c.GetOrSet("key", 0, func() (interface{}, error) { return 2, nil })
+
+	if got := c.Stats(); got.Misses != 1 || got.Hits != 1 {
+		t.Errorf("Stats() = %+v, want 1 miss and 1 hit", got)
+	}
+}
@@ -0,0 +1,71 @@
+package car
+
+import (
+	"net/http"
+
+	"github.com/joshbarros/golang-carflow-api/internal/apierror"
+)
+
+// BatchFailure describes one input of a bulk operation that failed. Index
+// counts from 0 in input order.
+type BatchFailure[T any] struct {
+	Index int           `json:"index"`
+	Input T             `json:"input"`
+	Code  apierror.Code `json:"code"`
+	Error string        `json:"error"`
+	Field string        `json:"field,omitempty"`
+}
+
+// BatchSummary counts the outcomes of a bulk operation
+type BatchSummary struct {
+	Total     int `json:"total"`
+	Succeeded int `json:"succeeded"`
+	Failed    int `json:"failed"`
+}
+
+// BatchResult is the response of every bulk operation: the items that
+// succeeded, the inputs that failed and why, and summary counts. In a dry
+// run Succeeded holds the items that would have succeeded.
+type BatchResult[T any] struct {
+	DryRun    bool              `json:"dry_run,omitempty"`
+	Summary   BatchSummary      `json:"summary"`
+	Succeeded []T               `json:"succeeded"`
+	Failed    []BatchFailure[T] `json:"failed"`
+}
+
+// newBatchResult creates an empty result for a bulk operation over total
+// inputs
+func newBatchResult[T any](total int) BatchResult[T] {
+	return BatchResult[T]{
+		Summary:   BatchSummary{Total: total},
+		Succeeded: []T{},
+		Failed:    []BatchFailure[T]{},
+	}
+}
+
+// succeed records an item that succeeded
+func (r *BatchResult[T]) succeed(item T) {
+	r.Succeeded = append(r.Succeeded, item)
+	r.Summary.Succeeded++
+}
+
+// fail records the input at index and the error it failed with
+func (r *BatchResult[T]) fail(index int, input T, err error) {
+	r.Failed = append(r.Failed, BatchFailure[T]{
+		Index: index,
+		Input: input,
+		Code:  errorCode(err),
+		Error: err.Error(),
+		Field: errorField(err),
+	})
+	r.Summary.Failed++
+}
+
+// Status returns success when every input succeeded and 207 Multi-Status
+// when any failed
+func (r BatchResult[T]) Status(success int) int {
+	if r.Summary.Failed > 0 {
+		return http.StatusMultiStatus
+	}
+	return success
+}
@@ -1,137 +1,466 @@
 package car
 
 import (
+	"encoding/csv"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
 	"net/http"
-	"regexp"
+	"net/url"
+	"slices"
 	"strconv"
 	"strings"
+	"time"
+
+	"github.com/joshbarros/golang-carflow-api/internal/apierror"
+	"github.com/joshbarros/golang-carflow-api/internal/jobs"
+	"github.com/joshbarros/golang-carflow-api/internal/pagination"
+	"github.com/joshbarros/golang-carflow-api/internal/tenant"
 )
 
+// sortableFields lists the car fields that can be used with the sort parameter
+var sortableFields = []string{"id", "make", "model", "year", "color", "created_at"}
+
+// groupableFields lists the car fields that can be used with group_by
+var groupableFields = []string{"make", "model", "year"}
+
+// DefaultMaxBatchSize is the default limit on cars per batch request
+const DefaultMaxBatchSize = 1000
+
+// defaultSortOrders holds the sort direction used when a request doesn't
+// specify one. Fields not listed default to ascending.
+var defaultSortOrders = map[string]string{
+	"year":       "desc", // newest cars first
+	"created_at": "desc", // most recently added first
+}
+
 // Handler handles HTTP requests for car endpoints
 type Handler struct {
-	service *Service
+	service          *Service
+	validationStatus int
+	adminCheck       func(*http.Request) bool
+	maxBatchSize     int
+	jobs             *jobs.Store
+}
+
+// HandlerOption configures optional Handler behavior
+type HandlerOption func(*Handler)
+
+// WithValidationStatus sets the status code returned for invalid car data,
+// typically 400 Bad Request or 422 Unprocessable Entity
+func WithValidationStatus(code int) HandlerOption {
+	return func(h *Handler) {
+		h.validationStatus = code
+	}
+}
+
+// WithAdminCheck sets how admin requests are recognized. Admins may use
+// include_deleted and restore deleted cars; without a check those are always
+// refused.
+func WithAdminCheck(isAdmin func(*http.Request) bool) HandlerOption {
+	return func(h *Handler) {
+		h.adminCheck = isAdmin
+	}
+}
+
+// WithMaxBatchSize limits how many cars a batch request may contain; larger
+// batches are rejected with 413
+func WithMaxBatchSize(n int) HandlerOption {
+	return func(h *Handler) {
+		h.maxBatchSize = n
+	}
+}
+
+// WithJobStore enables asynchronous batches (?async=true), tracking their
+// progress in the given store
+func WithJobStore(store *jobs.Store) HandlerOption {
+	return func(h *Handler) {
+		h.jobs = store
+	}
 }
 
 // NewHandler creates a new car handler
-func NewHandler(service *Service) *Handler {
-	return &Handler{
-		service: service,
+func NewHandler(service *Service, opts ...HandlerOption) *Handler {
+	h := &Handler{
+		service:          service,
+		validationStatus: http.StatusBadRequest,
+		maxBatchSize:     DefaultMaxBatchSize,
+	}
+
+	for _, opt := range opts {
+		opt(h)
 	}
+
+	return h
 }
 
-// RegisterRoutes registers the car endpoints to the given ServeMux
-func (h *Handler) RegisterRoutes(mux *http.ServeMux) {
+// isAdmin reports whether the request was made by an admin
+func (h *Handler) isAdmin(r *http.Request) bool {
+	return h.adminCheck != nil && h.adminCheck(r)
+}
+
+// Mux is the part of *http.ServeMux that RegisterRoutes uses. cmd/main.go
+// passes a router that also records the routes for the OpenAPI spec.
+type Mux interface {
+	HandleFunc(pattern string, handler func(http.ResponseWriter, *http.Request))
+}
+
+// RegisterRoutes registers the car endpoints on mux
+func (h *Handler) RegisterRoutes(mux Mux) {
 	mux.HandleFunc("GET /cars", h.handleGetAllCars)
+	mux.HandleFunc("GET /cars/count", h.handleCountCars)
+	mux.HandleFunc("GET /cars/export", h.handleExportCars)
+	mux.HandleFunc("GET /cars/stats/timeline", h.handleGetTimeline)
+	mux.HandleFunc("GET /cars/duplicates", h.handleGetDuplicates)
+	mux.HandleFunc("GET /cars/compare", h.handleCompareCars)
 	mux.HandleFunc("GET /cars/{id}", h.handleGetCar)
+	mux.HandleFunc("GET /cars/{id}/{sub}", h.handleGetCarSubresource)
 	mux.HandleFunc("POST /cars", h.handleCreateCar)
+	mux.HandleFunc("POST /cars/batch", h.handleCreateCarsBatch)
+	mux.HandleFunc("POST /cars/import", h.handleImportCars)
 	mux.HandleFunc("PUT /cars/{id}", h.handleUpdateCar)
+	mux.HandleFunc("PATCH /cars/{id}", h.handlePatchCar)
 	mux.HandleFunc("DELETE /cars/{id}", h.handleDeleteCar)
+	mux.HandleFunc("POST /cars/{id}/restore", h.handleRestoreCar)
 }
 
 // handleGetAllCars handles GET /cars requests
 func (h *Handler) handleGetAllCars(w http.ResponseWriter, r *http.Request) {
+	version, ok := requireVersion(w, r)
+	if !ok {
+		return
+	}
+
 	// Extract query parameters for filtering
 	query := r.URL.Query()
 
-	// Build filter options
-	filter := FilterOptions{
-		Make:  query.Get("make"),
-		Model: query.Get("model"),
-		Color: query.Get("color"),
+	filter, err := parseFilter(query)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, apierror.BadRequest, err.Error())
+		return
 	}
 
-	// Parse year if provided
-	if yearStr := query.Get("year"); yearStr != "" {
-		year, err := strconv.Atoi(yearStr)
-		if err != nil {
-			respondWithError(w, http.StatusBadRequest, "Invalid year parameter")
+	// Parse near_year if provided
+	if nearYearStr := query.Get("near_year"); nearYearStr != "" {
+		nearYear, err := strconv.Atoi(nearYearStr)
+		if err != nil || nearYear < 1 {
+			respondWithError(w, http.StatusBadRequest, apierror.BadRequest, "Invalid near_year parameter")
 			return
 		}
-		filter.Year = year
+		filter.NearYear = nearYear
+	}
+
+	// Soft-deleted cars are only visible to admins
+	if query.Get("include_deleted") == "true" {
+		if !h.isAdmin(r) {
+			respondWithError(w, http.StatusForbidden, apierror.Forbidden, "include_deleted requires admin authorization")
+			return
+		}
+		filter.IncludeDeleted = true
 	}
 
 	// Extract sorting parameters
-	var sortOptions *SortOptions
-	if sortField := query.Get("sort"); sortField != "" {
-		// Check if sort order is specified
-		order := "asc"
-		if sortField[0] == '-' {
-			order = "desc"
-			sortField = sortField[1:]
+	sortOptions, err := pagination.SortFromQuery(query, sortableFields, defaultSortOrders)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, apierror.BadRequest, err.Error())
+		return
+	}
+
+	// Grouped listings cover every matching car, so they aren't paginated
+	if groupBy := query.Get("group_by"); groupBy != "" {
+		if !slices.Contains(groupableFields, groupBy) {
+			respondWithError(w, http.StatusBadRequest, apierror.BadRequest, "Invalid group_by parameter (must be make, model or year)")
+			return
 		}
+		result := h.service.GetGroupedCars(tenant.FromContext(r.Context()), filter, sortOptions, groupBy)
+		respondWithVersion(w, version, http.StatusOK, result)
+		return
+	}
+
+	// Extract pagination parameters
+	params, err := pagination.FromQuery(query)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, apierror.BadRequest, err.Error())
+		return
+	}
 
-		// Validate sort field
-		validFields := map[string]bool{
-			"id":    true,
-			"make":  true,
-			"model": true,
-			"year":  true,
-			"color": true,
+	// Check if pagination is requested
+	if query.Get("pagination") == "false" {
+		// Get cars with filtering and sorting only (no pagination)
+		cars := h.service.GetFilteredCars(tenant.FromContext(r.Context()), filter, sortOptions)
+		respondWithVersion(w, version, http.StatusOK, cars)
+	} else {
+		// Get cars with filtering, sorting, and pagination
+		result := h.service.GetPagedCars(tenant.FromContext(r.Context()), filter, sortOptions, params)
+
+		// Echo the effective options when debugging is requested
+		if query.Get("debug") == "true" {
+			applied := AppliedOptions{
+				Make:           filter.Make,
+				Model:          filter.Model,
+				Year:           filter.Year,
+				Color:          filter.Color,
+				Match:          filter.Match,
+				MakePrefix:     filter.MakePrefix,
+				ModelPrefix:    filter.ModelPrefix,
+				IncludeDeleted: filter.IncludeDeleted,
+				Page:           result.Page,
+				PageSize:       result.PageSize,
+			}
+			if len(sortOptions) > 0 {
+				fields := make([]string, len(sortOptions))
+				orders := make([]string, len(sortOptions))
+				for i, opt := range sortOptions {
+					fields[i] = opt.Field
+					orders[i] = opt.Order
+				}
+				applied.Sort = strings.Join(fields, ",")
+				applied.Order = strings.Join(orders, ",")
+			}
+			result.Meta = &ResultMeta{Applied: applied}
 		}
 
-		if !validFields[sortField] {
-			respondWithError(w, http.StatusBadRequest, "Invalid sort field")
+		w.Header().Set("Link", pagination.LinkHeader(r.URL, result.Page, result.TotalPages))
+		respondWithVersion(w, version, http.StatusOK, result)
+	}
+}
+
+// handleCountCars handles GET /cars/count requests
+func (h *Handler) handleCountCars(w http.ResponseWriter, r *http.Request) {
+	filter, err := parseFilter(r.URL.Query())
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, apierror.BadRequest, err.Error())
+		return
+	}
+
+	count := h.service.CountCars(tenant.FromContext(r.Context()), filter)
+	respondWithJSON(w, http.StatusOK, map[string]int{"count": count})
+}
+
+// exportFlushInterval is the number of CSV rows written between flushes
+const exportFlushInterval = 100
+
+// handleExportCars handles GET /cars/export requests, streaming the
+// tenant's cars as CSV. It accepts the same filter and sort parameters as
+// GET /cars.
+func (h *Handler) handleExportCars(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	filter, err := parseFilter(query)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, apierror.BadRequest, err.Error())
+		return
+	}
+
+	sortOptions, err := pagination.SortFromQuery(query, sortableFields, defaultSortOrders)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, apierror.BadRequest, err.Error())
+		return
+	}
+
+	cars := h.service.GetFilteredCars(tenant.FromContext(r.Context()), filter, sortOptions)
+
+	w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	w.Header().Set("Content-Disposition", `attachment; filename="cars.csv"`)
+	// Exports aren't cached, which also keeps the ETag middleware from
+	// buffering the whole body
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(http.StatusOK)
+
+	rc := http.NewResponseController(w)
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"id", "make", "model", "year", "color"})
+
+	for i, car := range cars {
+		record := []string{car.ID, car.Make, car.Model, strconv.Itoa(car.Year), car.Color}
+		if err := cw.Write(record); err != nil {
+			// The client has gone away; headers are already sent
 			return
 		}
 
-		sortOptions = &SortOptions{
-			Field: sortField,
-			Order: order,
+		if (i+1)%exportFlushInterval == 0 {
+			cw.Flush()
+			rc.Flush()
 		}
 	}
 
-	// Extract pagination parameters
-	pagination := PaginationOptions{
-		Page:     1,
-		PageSize: 10,
+	cw.Flush()
+}
+
+// maxTimelineBuckets bounds the number of buckets a timeline request can
+// ask for
+const maxTimelineBuckets = 1000
+
+// defaultTimelineSpans is how far back a timeline reaches when the request
+// has no from date, as years, months and days
+var defaultTimelineSpans = map[string][3]int{
+	IntervalDay:   {0, 0, -29},
+	IntervalWeek:  {0, 0, -7 * 11},
+	IntervalMonth: {0, -11, 0},
+}
+
+// handleGetTimeline handles GET /cars/stats/timeline requests. from and to
+// are dates (YYYY-MM-DD); to defaults to today and from to 30 days, 12
+// weeks or 12 months earlier depending on the interval.
+func (h *Handler) handleGetTimeline(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	interval := query.Get("interval")
+	if interval == "" {
+		interval = IntervalDay
+	}
+	span, ok := defaultTimelineSpans[interval]
+	if !ok {
+		respondWithError(w, http.StatusBadRequest, apierror.BadRequest, "Invalid interval parameter (must be day, week or month)")
+		return
 	}
 
-	// Parse page parameter
-	if pageStr := query.Get("page"); pageStr != "" {
-		page, err := strconv.Atoi(pageStr)
-		if err != nil || page < 1 {
-			respondWithError(w, http.StatusBadRequest, "Invalid page parameter")
+	to := time.Now().UTC()
+	if value := query.Get("to"); value != "" {
+		parsed, err := time.Parse(time.DateOnly, value)
+		if err != nil {
+			respondWithError(w, http.StatusBadRequest, apierror.BadRequest, "Invalid to parameter (must be YYYY-MM-DD)")
 			return
 		}
-		pagination.Page = page
+		to = parsed
 	}
 
-	// Parse page_size parameter
-	if pageSizeStr := query.Get("page_size"); pageSizeStr != "" {
-		pageSize, err := strconv.Atoi(pageSizeStr)
-		if err != nil || pageSize < 1 || pageSize > 100 {
-			respondWithError(w, http.StatusBadRequest, "Invalid page_size parameter (must be between 1 and 100)")
+	from := to.AddDate(span[0], span[1], span[2])
+	if value := query.Get("from"); value != "" {
+		parsed, err := time.Parse(time.DateOnly, value)
+		if err != nil {
+			respondWithError(w, http.StatusBadRequest, apierror.BadRequest, "Invalid from parameter (must be YYYY-MM-DD)")
 			return
 		}
-		pagination.PageSize = pageSize
+		from = parsed
 	}
 
-	// Check if pagination is requested
-	if query.Get("pagination") == "false" {
-		// Get cars with filtering and sorting only (no pagination)
-		cars := h.service.GetFilteredCars(filter, sortOptions)
-		respondWithJSON(w, http.StatusOK, cars)
-	} else {
-		// Get cars with filtering, sorting, and pagination
-		result := h.service.GetPagedCars(filter, sortOptions, pagination)
-		respondWithJSON(w, http.StatusOK, result)
+	if from.After(to) {
+		respondWithError(w, http.StatusBadRequest, apierror.BadRequest, "from must not be after to")
+		return
+	}
+	if n, _ := timelineBucketCount(interval, from, to); n > maxTimelineBuckets {
+		respondWithError(w, http.StatusBadRequest, apierror.BadRequest, fmt.Sprintf("Date range spans %d %ss; the maximum is %d", n, interval, maxTimelineBuckets))
+		return
+	}
+
+	timeline, err := h.service.GetTimeline(tenant.FromContext(r.Context()), interval, from, to)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, apierror.InternalError, "Internal server error")
+		return
 	}
+
+	respondWithJSON(w, http.StatusOK, timeline)
+}
+
+// handleGetDuplicates handles GET /cars/duplicates requests
+func (h *Handler) handleGetDuplicates(w http.ResponseWriter, r *http.Request) {
+	duplicates := h.service.FindDuplicates(tenant.FromContext(r.Context()))
+	respondWithJSON(w, http.StatusOK, map[string]interface{}{
+		"duplicates": duplicates,
+	})
+}
+
+// handleCompareCars handles GET /cars/compare?ids=a,b requests
+func (h *Handler) handleCompareCars(w http.ResponseWriter, r *http.Request) {
+	var ids []string
+	for _, id := range strings.Split(r.URL.Query().Get("ids"), ",") {
+		if id = strings.TrimSpace(id); id != "" {
+			ids = append(ids, id)
+		}
+	}
+	if len(ids) != 2 {
+		respondWithError(w, http.StatusBadRequest, apierror.BadRequest, "ids must list exactly two car IDs")
+		return
+	}
+
+	comparison, err := h.service.CompareCars(tenant.FromContext(r.Context()), ids[0], ids[1])
+	if err != nil {
+		switch err {
+		case ErrNotFound:
+			respondWithError(w, http.StatusNotFound, apierror.CarNotFound, "Car not found")
+		default:
+			respondWithError(w, http.StatusInternalServerError, apierror.InternalError, "Internal server error")
+		}
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, comparison)
 }
 
 // handleGetCar handles GET /cars/{id} requests
 func (h *Handler) handleGetCar(w http.ResponseWriter, r *http.Request) {
+	version, ok := requireVersion(w, r)
+	if !ok {
+		return
+	}
+
 	id := strings.TrimPrefix(r.URL.Path, "/cars/")
-	car, err := h.service.GetCar(id)
+	car, err := h.service.GetCar(id, tenant.FromContext(r.Context()))
 
 	if err != nil {
 		switch err {
 		case ErrNotFound:
-			respondWithError(w, http.StatusNotFound, "Car not found")
+			respondWithError(w, http.StatusNotFound, apierror.CarNotFound, "Car not found")
 		case ErrInvalidID:
-			respondWithError(w, http.StatusBadRequest, "Invalid car ID")
+			respondWithError(w, http.StatusBadRequest, apierror.BadRequest, "Invalid car ID")
 		default:
-			respondWithError(w, http.StatusInternalServerError, "Internal server error")
+			respondWithError(w, http.StatusInternalServerError, apierror.InternalError, "Internal server error")
+		}
+		return
+	}
+
+	respondWithVersion(w, version, http.StatusOK, car)
+}
+
+// handleGetCarSubresource serves GET /cars/vin/{vin} and
+// GET /cars/{id}/valuation. ServeMux can't register both since
+// /cars/vin/valuation would match either, so one route dispatches them.
+// The VIN lookup wins for that path.
+func (h *Handler) handleGetCarSubresource(w http.ResponseWriter, r *http.Request) {
+	switch {
+	case r.PathValue("id") == "vin":
+		r.SetPathValue("vin", r.PathValue("sub"))
+		h.handleGetCarByVIN(w, r)
+	case r.PathValue("sub") == "valuation":
+		h.handleGetValuation(w, r)
+	default:
+		respondWithError(w, http.StatusNotFound, apierror.NotFound, "Not found")
+	}
+}
+
+// handleGetValuation handles GET /cars/{id}/valuation requests
+func (h *Handler) handleGetValuation(w http.ResponseWriter, r *http.Request) {
+	valuation, err := h.service.ValueCar(r.Context(), r.PathValue("id"), tenant.FromContext(r.Context()), time.Now())
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrNotFound):
+			respondWithError(w, http.StatusNotFound, apierror.CarNotFound, "Car not found")
+		case errors.Is(err, ErrValuationUnavailable):
+			respondWithError(w, http.StatusBadGateway, apierror.UpstreamUnavailable, "Valuation is currently unavailable")
+		default:
+			respondWithError(w, http.StatusInternalServerError, apierror.InternalError, "Internal server error")
+		}
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, valuation)
+}
+
+// handleGetCarByVIN handles GET /cars/vin/{vin} requests
+func (h *Handler) handleGetCarByVIN(w http.ResponseWriter, r *http.Request) {
+	car, err := h.service.GetCarByVIN(r.PathValue("vin"), tenant.FromContext(r.Context()))
+	if err != nil {
+		var validationErr *ValidationError
+		switch {
+		case errors.Is(err, ErrNotFound):
+			respondWithError(w, http.StatusNotFound, apierror.CarNotFound, "Car not found")
+		case errors.As(err, &validationErr):
+			respondWithValidationError(w, http.StatusBadRequest, apierror.ValidationFailed, validationErr)
+		default:
+			respondWithError(w, http.StatusInternalServerError, apierror.InternalError, "Internal server error")
 		}
 		return
 	}
@@ -142,25 +471,31 @@ func (h *Handler) handleGetCar(w http.ResponseWriter, r *http.Request) {
 // handleCreateCar handles POST /cars requests
 func (h *Handler) handleCreateCar(w http.ResponseWriter, r *http.Request) {
 	var car Car
-	if err := json.NewDecoder(r.Body).Decode(&car); err != nil {
-		respondWithError(w, http.StatusBadRequest, "Invalid request payload")
+	if err := decodeJSON(r, &car); err != nil {
+		respondWithError(w, http.StatusBadRequest, apierror.BadRequest, err.Error())
 		return
 	}
 	defer r.Body.Close()
 
+	// If-None-Match: * asks for the car to be created only if it doesn't exist
+	createIfAbsent := r.Header.Get("If-None-Match") == "*"
+
+	// The tenant always comes from the request, never from the body
+	car.TenantID = tenant.FromContext(r.Context())
+
 	createdCar, err := h.service.CreateCar(car)
 	if err != nil {
+		var validationErr *ValidationError
+		var conflictErr *ConflictError
 		switch {
-		case strings.Contains(err.Error(), "ID is required") ||
-			strings.Contains(err.Error(), "make is required") ||
-			strings.Contains(err.Error(), "model is required") ||
-			strings.Contains(err.Error(), "year must be between") ||
-			strings.Contains(err.Error(), "color must be"):
-			respondWithError(w, http.StatusBadRequest, err.Error())
-		case strings.Contains(err.Error(), "already exists"):
-			respondWithError(w, http.StatusConflict, err.Error())
+		case errors.As(err, &validationErr):
+			respondWithValidationError(w, h.validationStatus, apierror.ValidationFailed, validationErr)
+		case errors.Is(err, ErrConflict) && createIfAbsent:
+			respondWithError(w, http.StatusPreconditionFailed, apierror.PreconditionFailed, "Precondition failed: car with this ID already exists")
+		case errors.As(err, &conflictErr):
+			respondWithConflict(w, conflictErr)
 		default:
-			respondWithError(w, http.StatusInternalServerError, "Internal server error")
+			respondWithError(w, http.StatusInternalServerError, apierror.InternalError, "Internal server error")
 		}
 		return
 	}
@@ -168,41 +503,134 @@ func (h *Handler) handleCreateCar(w http.ResponseWriter, r *http.Request) {
 	respondWithJSON(w, http.StatusCreated, createdCar)
 }
 
-// handleUpdateCar handles PUT /cars/{id} requests
-func (h *Handler) handleUpdateCar(w http.ResponseWriter, r *http.Request) {
-	idPattern := regexp.MustCompile(`/cars/([^/]+)$`)
-	matches := idPattern.FindStringSubmatch(r.URL.Path)
+// handleCreateCarsBatch handles POST /cars/batch requests
+func (h *Handler) handleCreateCarsBatch(w http.ResponseWriter, r *http.Request) {
+	var cars []Car
+	if err := decodeJSON(r, &cars); err != nil {
+		respondWithError(w, http.StatusBadRequest, apierror.BadRequest, err.Error())
+		return
+	}
+	defer r.Body.Close()
 
-	if len(matches) < 2 {
-		respondWithError(w, http.StatusBadRequest, "Invalid car ID")
+	if len(cars) == 0 {
+		respondWithError(w, http.StatusBadRequest, apierror.BadRequest, "Request must contain at least one car")
+		return
+	}
+	if len(cars) > h.maxBatchSize {
+		respondWithError(w, http.StatusRequestEntityTooLarge, apierror.PayloadTooLarge, fmt.Sprintf("Batch exceeds the maximum of %d cars", h.maxBatchSize))
 		return
 	}
 
-	id := matches[1]
+	tenantID := tenant.FromContext(r.Context())
+
+	// In async mode the batch runs in the background and is polled via /jobs/{id}
+	if r.URL.Query().Get("async") == "true" {
+		if h.jobs == nil {
+			respondWithError(w, http.StatusBadRequest, apierror.BadRequest, "Asynchronous batches are not enabled")
+			return
+		}
+
+		job, err := h.jobs.Create(tenantID, len(cars))
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, apierror.InternalError, "Internal server error")
+			return
+		}
+
+		go func() {
+			result := h.service.CreateCarsWithProgress(tenantID, cars, func(processed, failed int) {
+				h.jobs.Progress(tenantID, job.ID, processed, failed)
+			})
+			h.jobs.Complete(tenantID, job.ID, result)
+		}()
+
+		w.Header().Set("Location", "/jobs/"+job.ID)
+		respondWithJSON(w, http.StatusAccepted, job)
+		return
+	}
+
+	// 201 when everything was created, 207 when any item failed
+	result := h.service.CreateCars(tenantID, cars)
+	respondWithJSON(w, result.Status(http.StatusCreated), result)
+}
+
+// handleImportCars handles POST /cars/import requests. The body is either a
+// JSON array of cars or CSV with a header row, chosen by Content-Type. With
+// dry_run=true the import is only validated and nothing is written.
+func (h *Handler) handleImportCars(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+
+	var rows []ImportRow
+	var err error
+	switch mediaType {
+	case "application/json":
+		var cars []Car
+		err = decodeJSON(r, &cars)
+		for _, car := range cars {
+			rows = append(rows, ImportRow{Car: car})
+		}
+	case "text/csv":
+		rows, err = parseCarsCSV(r.Body)
+	default:
+		respondWithError(w, http.StatusUnsupportedMediaType, apierror.UnsupportedMediaType, "Content-Type must be application/json or text/csv")
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, apierror.BadRequest, err.Error())
+		return
+	}
+
+	if len(rows) == 0 {
+		respondWithError(w, http.StatusBadRequest, apierror.BadRequest, "Import must contain at least one car")
+		return
+	}
+	if len(rows) > h.maxBatchSize {
+		respondWithError(w, http.StatusRequestEntityTooLarge, apierror.PayloadTooLarge, fmt.Sprintf("Import exceeds the maximum of %d cars", h.maxBatchSize))
+		return
+	}
+
+	dryRun := r.URL.Query().Get("dry_run") == "true"
+	result := h.service.ImportCars(tenant.FromContext(r.Context()), rows, dryRun)
+
+	// A dry run reports without creating anything; a real run uses 201 when
+	// every row was created and 207 when any was rejected
+	status := http.StatusOK
+	if !dryRun {
+		status = result.Status(http.StatusCreated)
+	}
+
+	respondWithJSON(w, status, result)
+}
+
+// handleUpdateCar handles PUT /cars/{id} requests
+func (h *Handler) handleUpdateCar(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
 
 	var car Car
-	if err := json.NewDecoder(r.Body).Decode(&car); err != nil {
-		respondWithError(w, http.StatusBadRequest, "Invalid request payload")
+	if err := decodeJSON(r, &car); err != nil {
+		respondWithError(w, http.StatusBadRequest, apierror.BadRequest, err.Error())
 		return
 	}
 	defer r.Body.Close()
 
 	// Ensure the ID in the URL matches the ID in the body
 	car.ID = id
+	car.TenantID = tenant.FromContext(r.Context())
 
 	updatedCar, err := h.service.UpdateCar(car)
 	if err != nil {
+		var validationErr *ValidationError
+		var conflictErr *ConflictError
 		switch {
 		case err == ErrNotFound:
-			respondWithError(w, http.StatusNotFound, "Car not found")
-		case strings.Contains(err.Error(), "ID is required") ||
-			strings.Contains(err.Error(), "make is required") ||
-			strings.Contains(err.Error(), "model is required") ||
-			strings.Contains(err.Error(), "year must be between") ||
-			strings.Contains(err.Error(), "color must be"):
-			respondWithError(w, http.StatusBadRequest, err.Error())
+			respondWithError(w, http.StatusNotFound, apierror.CarNotFound, "Car not found")
+		case errors.As(err, &validationErr):
+			respondWithValidationError(w, h.validationStatus, apierror.ValidationFailed, validationErr)
+		case errors.As(err, &conflictErr):
+			respondWithConflict(w, conflictErr)
 		default:
-			respondWithError(w, http.StatusInternalServerError, "Internal server error")
+			respondWithError(w, http.StatusInternalServerError, apierror.InternalError, "Internal server error")
 		}
 		return
 	}
@@ -210,27 +638,50 @@ func (h *Handler) handleUpdateCar(w http.ResponseWriter, r *http.Request) {
 	respondWithJSON(w, http.StatusOK, updatedCar)
 }
 
-// handleDeleteCar handles DELETE /cars/{id} requests
-func (h *Handler) handleDeleteCar(w http.ResponseWriter, r *http.Request) {
-	idPattern := regexp.MustCompile(`/cars/([^/]+)$`)
-	matches := idPattern.FindStringSubmatch(r.URL.Path)
+// handlePatchCar handles PATCH /cars/{id} requests
+func (h *Handler) handlePatchCar(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
 
-	if len(matches) < 2 {
-		respondWithError(w, http.StatusBadRequest, "Invalid car ID")
+	var patch CarPatch
+	if err := decodeJSONStrict(r, &patch); err != nil {
+		respondWithError(w, http.StatusBadRequest, apierror.BadRequest, err.Error())
 		return
 	}
+	defer r.Body.Close()
 
-	id := matches[1]
+	patchedCar, err := h.service.PatchCar(id, tenant.FromContext(r.Context()), patch)
+	if err != nil {
+		var validationErr *ValidationError
+		var conflictErr *ConflictError
+		switch {
+		case err == ErrNotFound:
+			respondWithError(w, http.StatusNotFound, apierror.CarNotFound, "Car not found")
+		case errors.As(err, &validationErr):
+			respondWithValidationError(w, h.validationStatus, apierror.ValidationFailed, validationErr)
+		case errors.As(err, &conflictErr):
+			respondWithConflict(w, conflictErr)
+		default:
+			respondWithError(w, http.StatusInternalServerError, apierror.InternalError, "Internal server error")
+		}
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, patchedCar)
+}
 
-	err := h.service.DeleteCar(id)
+// handleDeleteCar handles DELETE /cars/{id} requests
+func (h *Handler) handleDeleteCar(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+
+	err := h.service.DeleteCar(id, tenant.FromContext(r.Context()))
 	if err != nil {
 		switch err {
 		case ErrNotFound:
-			respondWithError(w, http.StatusNotFound, "Car not found")
+			respondWithError(w, http.StatusNotFound, apierror.CarNotFound, "Car not found")
 		case ErrInvalidID:
-			respondWithError(w, http.StatusBadRequest, "Invalid car ID")
+			respondWithError(w, http.StatusBadRequest, apierror.BadRequest, "Invalid car ID")
 		default:
-			respondWithError(w, http.StatusInternalServerError, "Internal server error")
+			respondWithError(w, http.StatusInternalServerError, apierror.InternalError, "Internal server error")
 		}
 		return
 	}
@@ -239,21 +690,207 @@ func (h *Handler) handleDeleteCar(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusNoContent)
 }
 
-// respondWithError sends an error response to the client
-func respondWithError(w http.ResponseWriter, code int, message string) {
-	respondWithJSON(w, code, map[string]string{"error": message})
+// handleRestoreCar handles POST /cars/{id}/restore requests
+func (h *Handler) handleRestoreCar(w http.ResponseWriter, r *http.Request) {
+	// Deleted cars are only visible to admins, so only they may restore them
+	if !h.isAdmin(r) {
+		respondWithError(w, http.StatusForbidden, apierror.Forbidden, "Restoring a car requires admin authorization")
+		return
+	}
+
+	car, err := h.service.RestoreCar(r.PathValue("id"), tenant.FromContext(r.Context()))
+	if err != nil {
+		var conflictErr *ConflictError
+		switch {
+		case err == ErrNotFound:
+			respondWithError(w, http.StatusNotFound, apierror.CarNotFound, "Car not found")
+		case errors.As(err, &conflictErr):
+			respondWithConflict(w, conflictErr)
+		case err == ErrInvalidID:
+			respondWithError(w, http.StatusBadRequest, apierror.BadRequest, "Invalid car ID")
+		default:
+			respondWithError(w, http.StatusInternalServerError, apierror.InternalError, "Internal server error")
+		}
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, car)
+}
+
+// parseFilter builds the make, model, year and color filters, plus the
+// match mode and the make_prefix and model_prefix filters, from the query
+func parseFilter(query url.Values) (FilterOptions, error) {
+	filter := FilterOptions{
+		Make:        query.Get("make"),
+		Model:       query.Get("model"),
+		Color:       query.Get("color"),
+		MakePrefix:  query.Get("make_prefix"),
+		ModelPrefix: query.Get("model_prefix"),
+	}
+
+	switch match := query.Get("match"); match {
+	case "", MatchExact:
+	case MatchContains:
+		filter.Match = MatchContains
+	default:
+		return FilterOptions{}, fmt.Errorf("invalid match %q (allowed: %s, %s)", match, MatchExact, MatchContains)
+	}
+
+	// Parse year if provided
+	if yearStr := query.Get("year"); yearStr != "" {
+		year, err := strconv.Atoi(yearStr)
+		if err != nil {
+			return FilterOptions{}, errors.New("Invalid year parameter")
+		}
+		filter.Year = year
+	}
+
+	return filter, nil
+}
+
+// decodeJSON decodes the request body into v, returning an error that
+// describes what was wrong with the payload
+func decodeJSON(r *http.Request, v interface{}) error {
+	return describeDecodeError(json.NewDecoder(r.Body).Decode(v))
+}
+
+// decodeJSONStrict is like decodeJSON but rejects fields not present in v
+func decodeJSONStrict(r *http.Request, v interface{}) error {
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+	return describeDecodeError(decoder.Decode(v))
+}
+
+// csvColumns lists the columns accepted in CSV imports. make, model and
+// year are required.
+var csvColumns = []string{"id", "make", "model", "year", "color", "vin"}
+
+// parseCarsCSV reads cars from CSV whose first row names the columns, in
+// any order and ignoring case. A malformed header or file fails the whole
+// import; a row whose year isn't a number only fails that row.
+func parseCarsCSV(body io.Reader) ([]ImportRow, error) {
+	reader := csv.NewReader(body)
+
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, errors.New("Invalid CSV: body is empty")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("Invalid CSV: %v", err)
+	}
+
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if !slices.Contains(csvColumns, name) {
+			return nil, fmt.Errorf("Invalid CSV: unknown column %q", name)
+		}
+		if _, dup := columns[name]; dup {
+			return nil, fmt.Errorf("Invalid CSV: duplicate column %q", name)
+		}
+		columns[name] = i
+	}
+	for _, required := range []string{"make", "model", "year"} {
+		if _, ok := columns[required]; !ok {
+			return nil, fmt.Errorf("Invalid CSV: missing column %q", required)
+		}
+	}
+
+	field := func(record []string, name string) string {
+		if i, ok := columns[name]; ok {
+			return strings.TrimSpace(record[i])
+		}
+		return ""
+	}
+
+	var rows []ImportRow
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("Invalid CSV: %v", err)
+		}
+
+		row := ImportRow{Car: Car{
+			ID:    field(record, "id"),
+			Make:  field(record, "make"),
+			Model: field(record, "model"),
+			Color: field(record, "color"),
+			VIN:   field(record, "vin"),
+		}}
+		if year, err := strconv.Atoi(field(record, "year")); err != nil {
+			row.Err = &ValidationError{Field: "year", Message: "year must be a number"}
+		} else {
+			row.Car.Year = year
+		}
+		rows = append(rows, row)
+	}
+
+	return rows, nil
+}
+
+// describeDecodeError turns a JSON decoding error into a client-facing
+// message naming the problem
+func describeDecodeError(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+
+	switch {
+	case errors.As(err, &syntaxErr):
+		return fmt.Errorf("Invalid request payload: malformed JSON at byte offset %d", syntaxErr.Offset)
+	case errors.As(err, &typeErr):
+		if typeErr.Field != "" {
+			return fmt.Errorf("Invalid request payload: field %q must be of type %s, got %s", typeErr.Field, typeErr.Type, typeErr.Value)
+		}
+		return fmt.Errorf("Invalid request payload: expected %s, got %s", typeErr.Type, typeErr.Value)
+	case errors.Is(err, io.EOF):
+		return errors.New("Invalid request payload: body is empty")
+	case errors.Is(err, io.ErrUnexpectedEOF):
+		return errors.New("Invalid request payload: unexpected end of JSON")
+	case strings.HasPrefix(err.Error(), "json: unknown field "):
+		return fmt.Errorf("Invalid request payload: unknown field %s", strings.TrimPrefix(err.Error(), "json: unknown field "))
+	default:
+		return fmt.Errorf("Invalid request payload: %v", err)
+	}
+}
+
+// respondWithError sends an error response with a machine-readable code
+func respondWithError(w http.ResponseWriter, status int, code apierror.Code, message string) {
+	apierror.Write(w, status, code, message)
+}
+
+// respondWithValidationError sends an error response naming the field at fault
+func respondWithValidationError(w http.ResponseWriter, status int, code apierror.Code, err *ValidationError) {
+	apierror.WriteBody(w, status, apierror.Body{Error: err.Message, Code: code, Field: err.Field})
+}
+
+// respondWithConflict sends a 409 naming the field that clashes with an
+// existing car
+func respondWithConflict(w http.ResponseWriter, err *ConflictError) {
+	apierror.WriteBody(w, http.StatusConflict, apierror.Body{Error: err.Error(), Code: apierror.Conflict, Field: err.Field})
 }
 
 // respondWithJSON sends a JSON response to the client
 func respondWithJSON(w http.ResponseWriter, code int, payload interface{}) {
+	respondWithJSONType(w, code, "application/json", payload)
+}
+
+// respondWithJSONType sends a JSON response with the given content type
+func respondWithJSONType(w http.ResponseWriter, code int, contentType string, payload interface{}) {
 	response, err := json.Marshal(payload)
 	if err != nil {
 		w.WriteHeader(http.StatusInternalServerError)
-		w.Write([]byte(`{"error":"Internal server error"}`))
+		w.Write([]byte(`{"error":"Internal server error","code":"internal_error"}`))
 		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Content-Type", contentType)
 	w.WriteHeader(code)
 	w.Write(response)
 }
@@ -0,0 +1,675 @@
+package car
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/joshbarros/golang-carflow-api/internal/jobs"
+	"github.com/joshbarros/golang-carflow-api/internal/openapi"
+	"github.com/joshbarros/golang-carflow-api/internal/pagination"
+	"github.com/joshbarros/golang-carflow-api/internal/tenant"
+)
+
+func TestSortDefaults(t *testing.T) {
+	tests := []struct {
+		query string
+		want  []SortOptions
+	}{
+		{query: "sort=year", want: []SortOptions{{Field: "year", Order: "desc"}}},
+		{query: "sort=created_at", want: []SortOptions{{Field: "created_at", Order: "desc"}}},
+		{query: "sort=created_at&order=asc", want: []SortOptions{{Field: "created_at", Order: "asc"}}},
+		{query: "sort=make", want: []SortOptions{{Field: "make", Order: "asc"}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			query, _ := url.ParseQuery(tt.query)
+			got, err := pagination.SortFromQuery(query, sortableFields, defaultSortOrders)
+			if err != nil {
+				t.Fatalf("SortFromQuery() error = %v", err)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("SortFromQuery() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDecodeJSON(t *testing.T) {
+	tests := []struct {
+		name    string
+		body    string
+		wantErr string
+	}{
+		{name: "Valid", body: `{"id":"1","make":"Toyota","year":2020}`},
+		{name: "Wrong type", body: `{"id":"1","year":"2020"}`, wantErr: `field "year" must be of type int, got string`},
+		{name: "Syntax error", body: `{"id":"1",}`, wantErr: "malformed JSON at byte offset 11"},
+		{name: "Empty body", body: ``, wantErr: "body is empty"},
+		{name: "Truncated", body: `{"id":"1"`, wantErr: "unexpected end of JSON"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/cars", strings.NewReader(tt.body))
+
+			var car Car
+			err := decodeJSON(req, &car)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("decodeJSON() error = %v, want nil", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("decodeJSON() error = %v, want it to contain %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestHandler_ValidationStatus(t *testing.T) {
+	tests := []struct {
+		name string
+		opts []HandlerOption
+		want int
+	}{
+		{name: "Default", want: http.StatusBadRequest},
+		{name: "Unprocessable entity", opts: []HandlerOption{WithValidationStatus(http.StatusUnprocessableEntity)}, want: http.StatusUnprocessableEntity},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := NewHandler(NewService(NewInMemoryRepository()), tt.opts...)
+			mux := http.NewServeMux()
+			handler.RegisterRoutes(mux)
+
+			body := `{"id":"v1","make":"","model":"Civic","year":2020}`
+			rec := httptest.NewRecorder()
+			mux.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/cars", strings.NewReader(body)))
+
+			if rec.Code != tt.want {
+				t.Errorf("status = %d, want %d", rec.Code, tt.want)
+			}
+
+			var resp map[string]string
+			if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+				t.Fatalf("failed to decode body: %v", err)
+			}
+			if resp["field"] != "make" || resp["error"] != "make is required" || resp["code"] != "validation_failed" {
+				t.Errorf("body = %v, want field make with message and code validation_failed", resp)
+			}
+
+			// Malformed bodies are always a 400
+			rec = httptest.NewRecorder()
+			mux.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/cars", strings.NewReader(`{`)))
+			if rec.Code != http.StatusBadRequest {
+				t.Errorf("malformed body status = %d, want %d", rec.Code, http.StatusBadRequest)
+			}
+		})
+	}
+}
+
+func TestHandler_PatchCarRejectsUnknownFields(t *testing.T) {
+	service := NewService(NewInMemoryRepository())
+	service.CreateCar(Car{ID: "patch-h1", TenantID: tenant.DefaultID, Make: "Mazda", Model: "3", Year: 2019})
+
+	mux := http.NewServeMux()
+	NewHandler(service).RegisterRoutes(mux)
+
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodPatch, "/cars/patch-h1", strings.NewReader(`{"colour":"red"}`)))
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("status = %d, want %d", rec.Code, http.StatusBadRequest)
+	}
+	if !strings.Contains(rec.Body.String(), `unknown field \"colour\"`) {
+		t.Errorf("body = %s, want it to name the unknown field", rec.Body.String())
+	}
+
+	rec = httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodPatch, "/cars/patch-h1", strings.NewReader(`{"year":2020}`)))
+	if rec.Code != http.StatusOK {
+		t.Errorf("status = %d, want %d", rec.Code, http.StatusOK)
+	}
+}
+
+func TestHandler_TenantIsolation(t *testing.T) {
+	service := NewService(NewInMemoryRepository())
+	mux := http.NewServeMux()
+	NewHandler(service).RegisterRoutes(mux)
+	handler := tenant.Middleware(mux)
+
+	do := func(method, path, tenantID, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set(tenant.Header, tenantID)
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := do(http.MethodPost, "/cars", "acme", `{"id":"t1","make":"Ford","model":"Focus","year":2020,"tenant_id":"other"}`)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("create status = %d, want %d", rec.Code, http.StatusCreated)
+	}
+	var created Car
+	json.NewDecoder(rec.Body).Decode(&created)
+	if created.TenantID != "acme" {
+		t.Errorf("TenantID = %q, want it taken from the header", created.TenantID)
+	}
+
+	if rec := do(http.MethodGet, "/cars/t1", "acme", ""); rec.Code != http.StatusOK {
+		t.Errorf("owner GET status = %d, want %d", rec.Code, http.StatusOK)
+	}
+	if rec := do(http.MethodGet, "/cars/t1", "globex", ""); rec.Code != http.StatusNotFound || !strings.Contains(rec.Body.String(), `"code":"car_not_found"`) {
+		t.Errorf("other tenant GET = %d %s, want 404 with code car_not_found", rec.Code, rec.Body.String())
+	}
+	if rec := do(http.MethodDelete, "/cars/t1", "globex", ""); rec.Code != http.StatusNotFound {
+		t.Errorf("other tenant DELETE status = %d, want %d", rec.Code, http.StatusNotFound)
+	}
+	if rec := do(http.MethodGet, "/cars/t1", "acme", ""); rec.Code != http.StatusOK {
+		t.Errorf("car should survive another tenant's DELETE, status = %d", rec.Code)
+	}
+}
+
+func TestHandler_CompareCars(t *testing.T) {
+	service := NewService(NewInMemoryRepository())
+	service.CreateCar(Car{ID: "a", TenantID: tenant.DefaultID, Make: "Mazda", Model: "3", Year: 2019})
+	service.CreateCar(Car{ID: "b", TenantID: tenant.DefaultID, Make: "Mazda", Model: "6", Year: 2019})
+
+	mux := http.NewServeMux()
+	NewHandler(service).RegisterRoutes(mux)
+
+	tests := []struct {
+		query string
+		want  int
+	}{
+		{"ids=a,b", http.StatusOK},
+		{"ids=a,%20b", http.StatusOK},
+		{"ids=a,missing", http.StatusNotFound},
+		{"ids=a", http.StatusBadRequest},
+		{"ids=a,b,c", http.StatusBadRequest},
+		{"", http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/cars/compare?"+tt.query, nil))
+			if rec.Code != tt.want {
+				t.Errorf("status = %d, want %d", rec.Code, tt.want)
+			}
+		})
+	}
+}
+
+func TestHandler_IncludeDeletedRequiresAdmin(t *testing.T) {
+	service := NewService(NewInMemoryRepository())
+	service.CreateCar(Car{ID: "gone", TenantID: tenant.DefaultID, Make: "Saab", Model: "900", Year: 1990})
+	service.DeleteCar("gone", tenant.DefaultID)
+
+	mux := http.NewServeMux()
+	isAdmin := func(r *http.Request) bool { return r.Header.Get("Authorization") == "Bearer secret" }
+	NewHandler(service, WithAdminCheck(isAdmin)).RegisterRoutes(mux)
+
+	tests := []struct {
+		name  string
+		auth  string
+		want  int
+		count int
+	}{
+		{"no token", "", http.StatusForbidden, 0},
+		{"wrong token", "Bearer nope", http.StatusForbidden, 0},
+		{"admin token", "Bearer secret", http.StatusOK, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/cars?pagination=false&include_deleted=true", nil)
+			if tt.auth != "" {
+				req.Header.Set("Authorization", tt.auth)
+			}
+			rec := httptest.NewRecorder()
+			mux.ServeHTTP(rec, req)
+			if rec.Code != tt.want {
+				t.Fatalf("status = %d, want %d", rec.Code, tt.want)
+			}
+			if tt.want != http.StatusOK {
+				return
+			}
+			var cars []Car
+			json.NewDecoder(rec.Body).Decode(&cars)
+			if len(cars) != tt.count || !cars[0].IsDeleted() {
+				t.Errorf("cars = %v, want the deleted car", cars)
+			}
+		})
+	}
+
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/cars/gone/restore", nil))
+	if rec.Code != http.StatusForbidden {
+		t.Errorf("restore without admin status = %d, want %d", rec.Code, http.StatusForbidden)
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/cars/gone/restore", nil)
+	req.Header.Set("Authorization", "Bearer secret")
+	rec = httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Errorf("admin restore status = %d, want %d", rec.Code, http.StatusOK)
+	}
+	rec = httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/cars/gone", nil))
+	if rec.Code != http.StatusOK {
+		t.Errorf("GET after restore status = %d, want %d", rec.Code, http.StatusOK)
+	}
+}
+
+func TestHandler_CountCars(t *testing.T) {
+	service := NewService(NewInMemoryRepository())
+	service.CreateCar(Car{ID: "c1", TenantID: tenant.DefaultID, Make: "Mazda", Model: "3", Year: 2019})
+	service.CreateCar(Car{ID: "c2", TenantID: tenant.DefaultID, Make: "Mazda", Model: "6", Year: 2020})
+	service.CreateCar(Car{ID: "c3", TenantID: tenant.DefaultID, Make: "Subaru", Model: "Outback", Year: 2020})
+
+	mux := http.NewServeMux()
+	NewHandler(service).RegisterRoutes(mux)
+
+	tests := []struct {
+		query     string
+		wantCode  int
+		wantCount int
+	}{
+		{"", http.StatusOK, 3},
+		{"make=mazda", http.StatusOK, 2},
+		{"year=2020", http.StatusOK, 2},
+		{"year=abc", http.StatusBadRequest, 0},
+		{"make=zd&match=contains", http.StatusOK, 2},
+		{"make=zd&match=exact", http.StatusOK, 0},
+		{"make=zd&match=fuzzy", http.StatusBadRequest, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/cars/count?"+tt.query, nil))
+			if rec.Code != tt.wantCode {
+				t.Fatalf("status = %d, want %d", rec.Code, tt.wantCode)
+			}
+			if tt.wantCode != http.StatusOK {
+				return
+			}
+			var body map[string]int
+			json.NewDecoder(rec.Body).Decode(&body)
+			if body["count"] != tt.wantCount {
+				t.Errorf("count = %d, want %d", body["count"], tt.wantCount)
+			}
+		})
+	}
+}
+
+func TestHandler_BatchLimitsAndAsync(t *testing.T) {
+	service := NewService(NewInMemoryRepository())
+	store := jobs.NewStore(jobs.DefaultRetention)
+	mux := http.NewServeMux()
+	NewHandler(service, WithMaxBatchSize(2), WithJobStore(store)).RegisterRoutes(mux)
+
+	tooMany := `[{"id":"b1","make":"Ford","model":"Focus","year":2020},{"id":"b2","make":"Ford","model":"Focus","year":2020},{"id":"b3","make":"Ford","model":"Focus","year":2020}]`
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/cars/batch", strings.NewReader(tooMany)))
+	if rec.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("oversized batch status = %d, want %d", rec.Code, http.StatusRequestEntityTooLarge)
+	}
+
+	batch := `[{"id":"b1","make":"Ford","model":"Focus","year":2020},{"id":"","make":"Ford","model":"Focus","year":2020}]`
+	rec = httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/cars/batch?async=true", strings.NewReader(batch)))
+	if rec.Code != http.StatusAccepted {
+		t.Fatalf("async batch status = %d, want %d", rec.Code, http.StatusAccepted)
+	}
+	var job jobs.Job
+	json.NewDecoder(rec.Body).Decode(&job)
+	if rec.Header().Get("Location") != "/jobs/"+job.ID {
+		t.Errorf("Location = %q, want /jobs/%s", rec.Header().Get("Location"), job.ID)
+	}
+
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		got, _ := store.Get(tenant.DefaultID, job.ID)
+		if got.Status == jobs.StatusCompleted {
+			if got.Processed != 2 || got.Errors != 1 {
+				t.Errorf("finished job = %+v, want 2 processed and 1 error", got)
+			}
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("async batch did not complete in time")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestHandler_GetCarByVIN(t *testing.T) {
+	service := NewService(NewInMemoryRepository())
+	service.CreateCar(Car{ID: "v1", TenantID: tenant.DefaultID, Make: "Honda", Model: "Accord", Year: 2003, VIN: "1HGCM82633A004352"})
+
+	mux := http.NewServeMux()
+	NewHandler(service).RegisterRoutes(mux)
+
+	tests := []struct {
+		name string
+		vin  string
+		want int
+	}{
+		{"exact", "1HGCM82633A004352", http.StatusOK},
+		{"lower case", "1hgcm82633a004352", http.StatusOK},
+		{"unknown", "11111111111111111", http.StatusNotFound},
+		{"malformed", "not-a-vin", http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/cars/vin/"+tt.vin, nil))
+			if rec.Code != tt.want {
+				t.Errorf("status = %d, want %d", rec.Code, tt.want)
+			}
+		})
+	}
+
+	body := `{"id":"v2","make":"Honda","model":"Accord","year":2003,"vin":"1HGCM82633A004352"}`
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/cars", strings.NewReader(body)))
+	if rec.Code != http.StatusConflict || !strings.Contains(rec.Body.String(), `"field":"vin"`) {
+		t.Errorf("duplicate VIN create = %d %s, want 409 naming the vin field", rec.Code, rec.Body.String())
+	}
+}
+
+func TestHandler_ExportCars(t *testing.T) {
+	service := NewService(NewInMemoryRepository())
+	service.CreateCar(Car{ID: "c1", TenantID: tenant.DefaultID, Make: "Mazda", Model: "3", Year: 2019, Color: "Red"})
+	service.CreateCar(Car{ID: "c2", TenantID: tenant.DefaultID, Make: "Mazda", Model: "6, Touring", Year: 2020, Color: "Blue"})
+	service.CreateCar(Car{ID: "c3", TenantID: tenant.DefaultID, Make: "Subaru", Model: "Outback", Year: 2021, Color: "Green"})
+	service.CreateCar(Car{ID: "o1", TenantID: "other", Make: "Mazda", Model: "CX-5", Year: 2022, Color: "White"})
+
+	mux := http.NewServeMux()
+	NewHandler(service).RegisterRoutes(mux)
+
+	tests := []struct {
+		query    string
+		wantCode int
+		wantBody string
+	}{
+		{"sort=id", http.StatusOK, "id,make,model,year,color\nc1,Mazda,3,2019,Red\nc2,Mazda,\"6, Touring\",2020,Blue\nc3,Subaru,Outback,2021,Green\n"},
+		{"make=mazda&sort=-year", http.StatusOK, "id,make,model,year,color\nc2,Mazda,\"6, Touring\",2020,Blue\nc1,Mazda,3,2019,Red\n"},
+		{"make=Tesla", http.StatusOK, "id,make,model,year,color\n"},
+		{"year=abc", http.StatusBadRequest, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/cars/export?"+tt.query, nil))
+			if rec.Code != tt.wantCode {
+				t.Fatalf("status = %d, want %d", rec.Code, tt.wantCode)
+			}
+			if tt.wantCode != http.StatusOK {
+				return
+			}
+			if ct := rec.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/csv") {
+				t.Errorf("Content-Type = %q, want text/csv", ct)
+			}
+			if cd := rec.Header().Get("Content-Disposition"); cd != `attachment; filename="cars.csv"` {
+				t.Errorf("Content-Disposition = %q", cd)
+			}
+			if got := rec.Body.String(); got != tt.wantBody {
+				t.Errorf("body = %q, want %q", got, tt.wantBody)
+			}
+		})
+	}
+}
+
+func TestHandler_ImportCars(t *testing.T) {
+	tests := []struct {
+		name        string
+		contentType string
+		query       string
+		body        string
+		wantCode    int
+		wantCreated int
+		wantStored  int
+	}{
+		{
+			name:        "csv",
+			contentType: "text/csv",
+			body:        "ID,Make,Model,Year,Color\nc1,Toyota,Corolla,2020,Blue\nc2,Honda,\"Civic, Sport\",2021,\n",
+			wantCode:    http.StatusCreated,
+			wantCreated: 2,
+			wantStored:  2,
+		},
+		{
+			name:        "csv with rejected row",
+			contentType: "text/csv; charset=utf-8",
+			body:        "make,model,year,id\nToyota,Corolla,2020,c1\nToyota,Corolla,1800,c2\n",
+			wantCode:    http.StatusMultiStatus,
+			wantCreated: 1,
+			wantStored:  1,
+		},
+		{
+			name:        "json dry run",
+			contentType: "application/json",
+			query:       "dry_run=true",
+			body:        `[{"id":"c1","make":"Toyota","model":"Corolla","year":2020}]`,
+			wantCode:    http.StatusOK,
+			wantCreated: 1,
+			wantStored:  0,
+		},
+		{
+			name:        "csv missing column",
+			contentType: "text/csv",
+			body:        "id,make,model\nc1,Toyota,Corolla\n",
+			wantCode:    http.StatusBadRequest,
+		},
+		{
+			name:        "csv unknown column",
+			contentType: "text/csv",
+			body:        "id,make,model,year,price\nc1,Toyota,Corolla,2020,100\n",
+			wantCode:    http.StatusBadRequest,
+		},
+		{
+			name:        "csv bad year",
+			contentType: "text/csv",
+			body:        "id,make,model,year\nc1,Toyota,Corolla,new\nc2,Toyota,Corolla,2020\n",
+			wantCode:    http.StatusMultiStatus,
+			wantCreated: 1,
+			wantStored:  1,
+		},
+		{
+			name:        "unsupported type",
+			contentType: "text/plain",
+			body:        "c1 Toyota Corolla 2020",
+			wantCode:    http.StatusUnsupportedMediaType,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := NewService(NewInMemoryRepository())
+			mux := http.NewServeMux()
+			NewHandler(service).RegisterRoutes(mux)
+
+			req := httptest.NewRequest(http.MethodPost, "/cars/import?"+tt.query, strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", tt.contentType)
+			rec := httptest.NewRecorder()
+			mux.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantCode {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.wantCode, rec.Body.String())
+			}
+			if got := len(service.GetAllCars(tenant.DefaultID)); got != tt.wantStored {
+				t.Errorf("stored cars = %d, want %d", got, tt.wantStored)
+			}
+			if rec.Code >= http.StatusBadRequest {
+				return
+			}
+
+			var result BatchResult[Car]
+			json.NewDecoder(rec.Body).Decode(&result)
+			if result.Summary.Succeeded != tt.wantCreated || len(result.Succeeded) != tt.wantCreated {
+				t.Errorf("succeeded = %d (%d cars), want %d", result.Summary.Succeeded, len(result.Succeeded), tt.wantCreated)
+			}
+		})
+	}
+}
+
+func TestDescribe_CoversRoutes(t *testing.T) {
+	router := openapi.NewRouter(http.NewServeMux())
+	NewHandler(NewService(NewInMemoryRepository())).RegisterRoutes(router)
+
+	spec := openapi.NewSpec("CarFlow API", "1.0.0", router)
+	Describe(spec)
+	doc := spec.Document()
+
+	for _, path := range []string{"/cars", "/cars/{id}", "/cars/vin/{vin}", "/cars/{id}/valuation"} {
+		if len(doc.Paths[path]) == 0 {
+			t.Errorf("spec is missing path %s", path)
+		}
+	}
+	for path, methods := range doc.Paths {
+		for method, op := range methods {
+			if op.Summary == "" {
+				t.Errorf("%s %s is registered but not described", strings.ToUpper(method), path)
+			}
+		}
+	}
+	for _, name := range []string{"Car", "PagedResult"} {
+		if doc.Components.Schemas[name] == nil {
+			t.Errorf("spec is missing schema %s", name)
+		}
+	}
+}
+
+func TestHandler_GetTimeline(t *testing.T) {
+	service := NewService(NewInMemoryRepository())
+	service.CreateCar(Car{ID: "c1", TenantID: tenant.DefaultID, Make: "Kia", Model: "Rio", Year: 2020})
+
+	mux := http.NewServeMux()
+	NewHandler(service).RegisterRoutes(mux)
+
+	tests := []struct {
+		query       string
+		wantCode    int
+		wantBuckets int
+	}{
+		{"", http.StatusOK, 30},
+		{"interval=week", http.StatusOK, 12},
+		{"interval=month&from=2024-01-15&to=2024-03-01", http.StatusOK, 3},
+		{"interval=year", http.StatusBadRequest, 0},
+		{"from=01/02/2024", http.StatusBadRequest, 0},
+		{"from=2024-02-01&to=2024-01-01", http.StatusBadRequest, 0},
+		{"from=2000-01-01&to=2024-01-01", http.StatusBadRequest, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/cars/stats/timeline?"+tt.query, nil))
+			if rec.Code != tt.wantCode {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.wantCode, rec.Body.String())
+			}
+			if tt.wantCode != http.StatusOK {
+				return
+			}
+
+			var timeline Timeline
+			json.NewDecoder(rec.Body).Decode(&timeline)
+			if len(timeline.Buckets) != tt.wantBuckets {
+				t.Errorf("buckets = %d, want %d", len(timeline.Buckets), tt.wantBuckets)
+			}
+		})
+	}
+}
+
+func TestHandler_GroupBy(t *testing.T) {
+	service := NewService(NewInMemoryRepository())
+	service.CreateCar(Car{ID: "c1", TenantID: tenant.DefaultID, Make: "Mazda", Model: "3", Year: 2019})
+	service.CreateCar(Car{ID: "c2", TenantID: tenant.DefaultID, Make: "Mazda", Model: "6", Year: 2020})
+	service.CreateCar(Car{ID: "c3", TenantID: tenant.DefaultID, Make: "Subaru", Model: "Outback", Year: 2020})
+
+	mux := http.NewServeMux()
+	NewHandler(service).RegisterRoutes(mux)
+
+	tests := []struct {
+		query      string
+		wantCode   int
+		wantGroups []string
+	}{
+		{"group_by=make", http.StatusOK, []string{"Mazda", "Subaru"}},
+		{"group_by=year&sort=-year", http.StatusOK, []string{"2020", "2019"}},
+		{"group_by=make&make=subaru", http.StatusOK, []string{"Subaru"}},
+		{"group_by=color", http.StatusBadRequest, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/cars?"+tt.query, nil))
+			if rec.Code != tt.wantCode {
+				t.Fatalf("status = %d, want %d", rec.Code, tt.wantCode)
+			}
+			if tt.wantCode != http.StatusOK {
+				return
+			}
+
+			var result GroupedResult
+			json.NewDecoder(rec.Body).Decode(&result)
+			var keys []string
+			for _, group := range result.Groups {
+				keys = append(keys, group.Key)
+			}
+			if !slices.Equal(keys, tt.wantGroups) {
+				t.Errorf("groups = %v, want %v", keys, tt.wantGroups)
+			}
+		})
+	}
+}
+
+func TestHandler_GetValuation(t *testing.T) {
+	service := NewService(NewInMemoryRepository())
+	service.CreateCar(Car{ID: "v1", TenantID: tenant.DefaultID, Make: "Honda", Model: "Accord", Year: 2003, VIN: "1HGCM82633A004352"})
+
+	mux := http.NewServeMux()
+	NewHandler(service).RegisterRoutes(mux)
+
+	tests := []struct {
+		name string
+		path string
+		want int
+	}{
+		{"valuation", "/cars/v1/valuation", http.StatusOK},
+		{"unknown car", "/cars/missing/valuation", http.StatusNotFound},
+		{"unknown sub-path", "/cars/v1/history", http.StatusNotFound},
+		{"VIN lookup", "/cars/vin/1HGCM82633A004352", http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.path, nil))
+			if rec.Code != tt.want {
+				t.Errorf("status = %d, want %d", rec.Code, tt.want)
+			}
+		})
+	}
+
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/cars/v1/valuation", nil))
+	var valuation Valuation
+	json.NewDecoder(rec.Body).Decode(&valuation)
+	if valuation.CarID != "v1" || valuation.Method != MethodDepreciation || valuation.Value <= 0 || valuation.Disclaimer == "" {
+		t.Errorf("valuation = %+v, want a depreciation estimate with a disclaimer", valuation)
+	}
+}
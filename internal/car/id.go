@@ -0,0 +1,90 @@
+package car
+
+import (
+	"crypto/rand"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// ID strategies
+const (
+	IDStrategyClient   = "client"
+	IDStrategyUUID     = "uuid"
+	IDStrategySequence = "sequence"
+)
+
+// IDStrategies lists the strategies accepted by NewIDGenerator
+var IDStrategies = []string{IDStrategyClient, IDStrategyUUID, IDStrategySequence}
+
+// idPattern restricts car IDs to letters, digits, dashes and underscores
+var idPattern = regexp.MustCompile(`^[a-zA-Z0-9_-]+$`)
+
+// ValidIDPrefix reports whether IDs generated with prefix are valid car IDs
+func ValidIDPrefix(prefix string) bool {
+	return prefix == "" || idPattern.MatchString(prefix)
+}
+
+// IDGenerator assigns IDs to cars created without one
+type IDGenerator interface {
+	NextID(tenantID string) (string, error)
+}
+
+// UUIDGenerator generates random version 4 UUIDs
+type UUIDGenerator struct{}
+
+// NextID returns a new random UUID
+func (UUIDGenerator) NextID(tenantID string) (string, error) {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return "", ErrIDGeneration
+	}
+
+	// Set the version (4) and variant (RFC 4122) bits
+	b[6] = (b[6] & 0x0f) | 0x40
+	b[8] = (b[8] & 0x3f) | 0x80
+
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16]), nil
+}
+
+// SequenceGenerator generates IDs made of a prefix and an increasing,
+// zero-padded number such as CAR-0001. Each tenant has its own sequence.
+type SequenceGenerator struct {
+	prefix   string
+	mu       sync.Mutex
+	counters map[string]uint64
+}
+
+// NewSequenceGenerator creates a sequence generator using the given prefix
+func NewSequenceGenerator(prefix string) *SequenceGenerator {
+	return &SequenceGenerator{
+		prefix:   prefix,
+		counters: make(map[string]uint64),
+	}
+}
+
+// NextID returns the next ID in the tenant's sequence
+func (g *SequenceGenerator) NextID(tenantID string) (string, error) {
+	g.mu.Lock()
+	g.counters[tenantID]++
+	n := g.counters[tenantID]
+	g.mu.Unlock()
+
+	return fmt.Sprintf("%s%04d", g.prefix, n), nil
+}
+
+// NewIDGenerator returns the generator for the named strategy. The "client"
+// strategy returns nil, meaning callers must supply their own IDs.
+func NewIDGenerator(strategy, prefix string) (IDGenerator, error) {
+	switch strategy {
+	case "", IDStrategyClient:
+		return nil, nil
+	case IDStrategyUUID:
+		return UUIDGenerator{}, nil
+	case IDStrategySequence:
+		return NewSequenceGenerator(prefix), nil
+	default:
+		return nil, fmt.Errorf("unknown ID strategy %q (allowed: %s)", strategy, strings.Join(IDStrategies, ", "))
+	}
+}
@@ -1,10 +1,54 @@
 package car
 
+import "github.com/joshbarros/golang-carflow-api/internal/timestamp"
+
 // Car represents a car entity in the system
 type Car struct {
-	ID    string `json:"id"`
-	Make  string `json:"make"`
-	Model string `json:"model"`
-	Year  int    `json:"year"`
-	Color string `json:"color"`
+	ID       string `json:"id"`
+	TenantID string `json:"tenant_id,omitempty"`
+	Make     string `json:"make"`
+	Model    string `json:"model"`
+	Year     int    `json:"year"`
+	Color    string `json:"color"`
+	// VIN is the optional 17-character vehicle identification number,
+	// unique within a tenant
+	VIN string `json:"vin,omitempty"`
+	// CreatedAt is set by the service when the car is created
+	CreatedAt timestamp.Time `json:"created_at"`
+	// DeletedAt is set when the car has been soft-deleted
+	DeletedAt *timestamp.Time `json:"deleted_at,omitempty"`
+}
+
+// IsDeleted reports whether the car has been soft-deleted
+func (c Car) IsDeleted() bool {
+	return c.DeletedAt != nil
+}
+
+// CarPatch holds a partial car update. Nil fields are left unchanged.
+type CarPatch struct {
+	Make  *string `json:"make"`
+	Model *string `json:"model"`
+	Year  *int    `json:"year"`
+	Color *string `json:"color"`
+	VIN   *string `json:"vin"`
+}
+
+// Apply returns a copy of car with the patch's non-nil fields applied
+func (p CarPatch) Apply(car Car) Car {
+	if p.Make != nil {
+		car.Make = *p.Make
+	}
+	if p.Model != nil {
+		car.Model = *p.Model
+	}
+	if p.Year != nil {
+		car.Year = *p.Year
+	}
+	if p.Color != nil {
+		car.Color = *p.Color
+	}
+	if p.VIN != nil {
+		car.VIN = *p.VIN
+	}
+	return car
 }
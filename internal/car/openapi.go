@@ -0,0 +1,213 @@
+package car
+
+import (
+	"github.com/joshbarros/golang-carflow-api/internal/openapi"
+)
+
+// filterParams are the query parameters accepted by parseFilter
+var filterParams = []openapi.Parameter{
+	openapi.QueryParam("make", "string", "Filter by make, ignoring case"),
+	openapi.QueryParam("model", "string", "Filter by model, ignoring case"),
+	openapi.QueryParam("year", "integer", "Filter by exact year"),
+	openapi.QueryParam("color", "string", "Filter by color, ignoring case"),
+	{Name: "match", In: "query", Description: "How make, model and color are matched", Schema: &openapi.Schema{Type: "string", Enum: []string{MatchExact, MatchContains}}},
+	openapi.QueryParam("make_prefix", "string", "Filter by the start of the make"),
+	openapi.QueryParam("model_prefix", "string", "Filter by the start of the model"),
+}
+
+// sortParams are the query parameters accepted by pagination.SortFromQuery
+var sortParams = []openapi.Parameter{
+	openapi.QueryParam("sort", "string", "Comma-separated fields to sort by (id, make, model, year, color, created_at); a leading - sorts descending"),
+	{Name: "order", In: "query", Description: "Sort direction for fields without a - prefix; year and created_at default to desc, other fields to asc", Schema: &openapi.Schema{Type: "string", Enum: []string{"asc", "desc"}}},
+}
+
+// Describe adds the car endpoints and the schemas they use to spec
+func Describe(spec *openapi.Spec) {
+	spec.AddSchema("Car", Car{})
+	spec.AddSchema("CarPatch", CarPatch{})
+	spec.AddSchema("PagedResult", PagedResult{})
+	spec.AddSchema("Comparison", Comparison{})
+	spec.AddSchema("DuplicateGroup", DuplicateGroup{})
+	spec.AddSchema("BatchResult", BatchResult[Car]{})
+	spec.AddSchema("Timeline", Timeline{})
+	spec.AddSchema("GroupedResult", GroupedResult{})
+	spec.AddSchema("Valuation", Valuation{})
+
+	spec.Expand("GET /cars/{id}/{sub}", "GET /cars/vin/{vin}", "GET /cars/{id}/valuation")
+
+	car := openapi.Ref("Car")
+	carBody := &openapi.RequestBody{Required: true, Content: openapi.JSON(car)}
+	notFound := openapi.ErrorResponse("Car not found")
+	invalid := openapi.ErrorResponse("Invalid request")
+
+	listParams := append(append([]openapi.Parameter{}, filterParams...), sortParams...)
+	listParams = append(listParams,
+		openapi.QueryParam("page", "integer", "Page number, starting at 1"),
+		openapi.QueryParam("page_size", "integer", "Cars per page (1-100)"),
+		openapi.QueryParam("pagination", "boolean", "Set to false to return every matching car as an array"),
+		openapi.QueryParam("near_year", "integer", "Order cars by closeness to this year"),
+		openapi.QueryParam("include_deleted", "boolean", "Include soft-deleted cars (admin only)"),
+		openapi.QueryParam("debug", "boolean", "Echo the applied options in meta"),
+		openapi.Parameter{Name: "group_by", In: "query", Description: "Return {\"groups\": [{\"key\", \"cars\"}]} grouped by this field instead of a page", Schema: &openapi.Schema{Type: "string", Enum: groupableFields}},
+	)
+
+	spec.Describe(map[string]openapi.Operation{
+		"GET /cars": {
+			Summary:    "List cars",
+			Parameters: listParams,
+			Responses: map[string]openapi.Response{
+				"200": {Description: "A page of cars, an array when pagination=false, or a GroupedResult with group_by", Content: openapi.JSON(&openapi.Schema{
+					OneOf: []*openapi.Schema{openapi.Ref("PagedResult"), openapi.ArrayOf(car), openapi.Ref("GroupedResult")},
+				})},
+				"400": invalid,
+				"403": openapi.ErrorResponse("include_deleted requires admin authorization"),
+				"406": openapi.ErrorResponse("Unsupported response version"),
+			},
+		},
+		"GET /cars/count": {
+			Summary:    "Count cars matching the filters",
+			Parameters: filterParams,
+			Responses: map[string]openapi.Response{
+				"200": {Description: "The number of matching cars", Content: openapi.JSON(&openapi.Schema{
+					Type:       "object",
+					Properties: map[string]*openapi.Schema{"count": {Type: "integer"}},
+				})},
+				"400": invalid,
+			},
+		},
+		"GET /cars/export": {
+			Summary:    "Download cars as CSV",
+			Parameters: append(append([]openapi.Parameter{}, filterParams...), sortParams...),
+			Responses: map[string]openapi.Response{
+				"200": {Description: "CSV with columns id,make,model,year,color", Content: map[string]openapi.MediaType{
+					"text/csv": {Schema: &openapi.Schema{Type: "string"}},
+				}},
+				"400": invalid,
+			},
+		},
+		"GET /cars/stats/timeline": {
+			Summary: "Count cars created per day, week or month",
+			Parameters: []openapi.Parameter{
+				{Name: "interval", In: "query", Description: "Bucket size (default day)", Schema: &openapi.Schema{Type: "string", Enum: []string{IntervalDay, IntervalWeek, IntervalMonth}}},
+				{Name: "from", In: "query", Description: "First date (YYYY-MM-DD); defaults to 30 days, 12 weeks or 12 months before to", Schema: &openapi.Schema{Type: "string", Format: "date"}},
+				{Name: "to", In: "query", Description: "Last date (YYYY-MM-DD); defaults to today", Schema: &openapi.Schema{Type: "string", Format: "date"}},
+			},
+			Responses: map[string]openapi.Response{
+				"200": {Description: "Car counts per bucket", Content: openapi.JSON(openapi.Ref("Timeline"))},
+				"400": invalid,
+			},
+		},
+		"GET /cars/duplicates": {
+			Summary: "List likely duplicate cars",
+			Responses: map[string]openapi.Response{
+				"200": {Description: "Groups of cars sharing make, model and year, or VIN", Content: openapi.JSON(openapi.ArrayOf(openapi.Ref("DuplicateGroup")))},
+			},
+		},
+		"GET /cars/compare": {
+			Summary:    "Compare two cars",
+			Parameters: []openapi.Parameter{{Name: "ids", In: "query", Required: true, Description: "Two comma-separated car IDs", Schema: &openapi.Schema{Type: "string"}}},
+			Responses: map[string]openapi.Response{
+				"200": {Description: "Both cars and the fields that differ", Content: openapi.JSON(openapi.Ref("Comparison"))},
+				"400": invalid,
+				"404": notFound,
+			},
+		},
+		"GET /cars/{id}": {
+			Summary: "Get a car by ID",
+			Responses: map[string]openapi.Response{
+				"200": {Description: "The car", Content: openapi.JSON(car)},
+				"404": notFound,
+				"406": openapi.ErrorResponse("Unsupported response version"),
+			},
+		},
+		"GET /cars/vin/{vin}": {
+			Summary: "Get a car by VIN",
+			Responses: map[string]openapi.Response{
+				"200": {Description: "The car", Content: openapi.JSON(car)},
+				"400": openapi.ErrorResponse("Invalid VIN"),
+				"404": notFound,
+			},
+		},
+		"POST /cars": {
+			Summary:     "Create a car",
+			RequestBody: carBody,
+			Responses: map[string]openapi.Response{
+				"201": {Description: "The created car", Content: openapi.JSON(car)},
+				"400": invalid,
+				"409": openapi.ErrorResponse("A car with this ID or VIN already exists"),
+			},
+		},
+		"POST /cars/batch": {
+			Summary:     "Create many cars",
+			Parameters:  []openapi.Parameter{openapi.QueryParam("async", "boolean", "Run the batch as a background job")},
+			RequestBody: &openapi.RequestBody{Required: true, Content: openapi.JSON(openapi.ArrayOf(car))},
+			Responses: map[string]openapi.Response{
+				"201": {Description: "Every car was created", Content: openapi.JSON(openapi.Ref("BatchResult"))},
+				"202": {Description: "The batch was queued as a job"},
+				"207": {Description: "Some cars were rejected", Content: openapi.JSON(openapi.Ref("BatchResult"))},
+				"400": invalid,
+				"413": openapi.ErrorResponse("Too many cars"),
+			},
+		},
+		"POST /cars/import": {
+			Summary:    "Import cars from CSV or JSON",
+			Parameters: []openapi.Parameter{openapi.QueryParam("dry_run", "boolean", "Only validate the import")},
+			RequestBody: &openapi.RequestBody{Required: true, Content: map[string]openapi.MediaType{
+				"application/json": {Schema: openapi.ArrayOf(car)},
+				"text/csv":         {Schema: &openapi.Schema{Type: "string"}},
+			}},
+			Responses: map[string]openapi.Response{
+				"200": {Description: "Dry run report", Content: openapi.JSON(openapi.Ref("BatchResult"))},
+				"201": {Description: "Every row was created", Content: openapi.JSON(openapi.Ref("BatchResult"))},
+				"207": {Description: "Some rows were rejected", Content: openapi.JSON(openapi.Ref("BatchResult"))},
+				"400": invalid,
+				"413": openapi.ErrorResponse("Too many cars"),
+				"415": openapi.ErrorResponse("Unsupported Content-Type"),
+			},
+		},
+		"PUT /cars/{id}": {
+			Summary:     "Replace a car",
+			RequestBody: carBody,
+			Responses: map[string]openapi.Response{
+				"200": {Description: "The updated car", Content: openapi.JSON(car)},
+				"400": invalid,
+				"404": notFound,
+				"409": openapi.ErrorResponse("A car with this VIN already exists"),
+			},
+		},
+		"PATCH /cars/{id}": {
+			Summary:     "Update some fields of a car",
+			RequestBody: &openapi.RequestBody{Required: true, Content: openapi.JSON(openapi.Ref("CarPatch"))},
+			Responses: map[string]openapi.Response{
+				"200": {Description: "The updated car", Content: openapi.JSON(car)},
+				"400": invalid,
+				"404": notFound,
+				"409": openapi.ErrorResponse("A car with this VIN already exists"),
+			},
+		},
+		"DELETE /cars/{id}": {
+			Summary: "Soft-delete a car",
+			Responses: map[string]openapi.Response{
+				"204": {Description: "The car was deleted"},
+				"404": notFound,
+			},
+		},
+		"GET /cars/{id}/valuation": {
+			Summary: "Get a car's estimated market value",
+			Responses: map[string]openapi.Response{
+				"200": {Description: "The car's estimated value", Content: openapi.JSON(openapi.Ref("Valuation"))},
+				"404": notFound,
+				"502": openapi.ErrorResponse("The valuation provider failed"),
+			},
+		},
+		"POST /cars/{id}/restore": {
+			Summary: "Restore a soft-deleted car (admin only)",
+			Responses: map[string]openapi.Response{
+				"200": {Description: "The restored car", Content: openapi.JSON(car)},
+				"403": openapi.ErrorResponse("Restoring requires admin authorization"),
+				"404": notFound,
+				"409": openapi.ErrorResponse("Another car now uses this VIN"),
+			},
+		},
+	})
+}
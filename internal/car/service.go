@@ -1,10 +1,20 @@
 package car
 
 import (
+	"cmp"
 	"errors"
+	"fmt"
 	"regexp"
+	"slices"
 	"sort"
+	"strconv"
 	"strings"
+	"time"
+
+	"github.com/joshbarros/golang-carflow-api/internal/apierror"
+	"github.com/joshbarros/golang-carflow-api/internal/cache"
+	"github.com/joshbarros/golang-carflow-api/internal/pagination"
+	"github.com/joshbarros/golang-carflow-api/internal/timestamp"
 )
 
 var (
@@ -14,199 +24,1037 @@ var (
 	ErrIDGeneration = errors.New("failed to generate ID")
 )
 
+// Match modes for the make, model and color filters
+const (
+	MatchExact    = "exact"
+	MatchContains = "contains"
+)
+
 // FilterOptions contains options for filtering cars
 type FilterOptions struct {
 	Make  string
 	Model string
 	Year  int
 	Color string
+	// Match selects how Make, Model and Color are compared: MatchExact (the
+	// default) or MatchContains. Both ignore case.
+	Match string
+	// MakePrefix and ModelPrefix match the start of the field, ignoring case
+	MakePrefix  string
+	ModelPrefix string
+	// NearYear, when set, orders results by distance from this year, closest first
+	NearYear int
+	// IncludeDeleted also returns soft-deleted cars
+	IncludeDeleted bool
 }
 
 // SortOptions contains options for sorting cars
-type SortOptions struct {
-	Field string
-	Order string // "asc" or "desc"
-}
+type SortOptions = pagination.SortOption
 
 // PaginationOptions contains options for paginating results
-type PaginationOptions struct {
-	Page     int
-	PageSize int
-}
+type PaginationOptions = pagination.Params
 
 // PagedResult represents a paginated result set
 type PagedResult struct {
-	Data       []Car `json:"data"`
-	TotalItems int   `json:"total_items"`
-	TotalPages int   `json:"total_pages"`
-	Page       int   `json:"page"`
-	PageSize   int   `json:"page_size"`
+	pagination.Result[Car]
+	Meta *ResultMeta `json:"meta,omitempty"`
+}
+
+// ResultMeta holds optional diagnostic information about a result set
+type ResultMeta struct {
+	Applied AppliedOptions `json:"applied"`
+}
+
+// AppliedOptions echoes the filters, sort and pagination actually applied
+type AppliedOptions struct {
+	Make        string `json:"make,omitempty"`
+	Model       string `json:"model,omitempty"`
+	Year        int    `json:"year,omitempty"`
+	Color       string `json:"color,omitempty"`
+	Match       string `json:"match,omitempty"`
+	MakePrefix  string `json:"make_prefix,omitempty"`
+	ModelPrefix string `json:"model_prefix,omitempty"`
+	// IncludeDeleted reports whether soft-deleted cars were included
+	IncludeDeleted bool `json:"include_deleted,omitempty"`
+	// Sort and Order list the sort fields and their directions, comma-separated
+	Sort     string `json:"sort,omitempty"`
+	Order    string `json:"order,omitempty"`
+	Page     int    `json:"page"`
+	PageSize int    `json:"page_size"`
+}
+
+// ValidationError describes why a car field is invalid
+type ValidationError struct {
+	Field   string `json:"field"`
+	Message string `json:"error"`
+}
+
+// Error returns the validation message
+func (e *ValidationError) Error() string {
+	return e.Message
+}
+
+// ConflictError reports that a car clashes with an existing one on Field.
+// It wraps ErrConflict or ErrDuplicateVIN.
+type ConflictError struct {
+	Field string
+	Err   error
+}
+
+// Error returns the message of the wrapped error
+func (e *ConflictError) Error() string {
+	return e.Err.Error()
+}
+
+// Unwrap returns the wrapped error
+func (e *ConflictError) Unwrap() error {
+	return e.Err
+}
+
+// asConflict wraps the repository's conflict errors in a ConflictError
+// naming the field, and returns other errors unchanged
+func asConflict(err error) error {
+	switch {
+	case errors.Is(err, ErrConflict):
+		return &ConflictError{Field: "id", Err: err}
+	case errors.Is(err, ErrDuplicateVIN):
+		return &ConflictError{Field: "vin", Err: err}
+	}
+	return err
+}
+
+// Criteria a duplicate group can match on
+const (
+	DuplicateByMakeModelYear = "make_model_year"
+	DuplicateByVIN           = "vin"
+)
+
+// DuplicateGroup represents a cluster of cars that are likely duplicates.
+// Groups matched on make, model and year carry those fields; groups matched
+// on VIN carry the VIN instead.
+type DuplicateGroup struct {
+	Criterion string   `json:"criterion"`
+	Make      string   `json:"make,omitempty"`
+	Model     string   `json:"model,omitempty"`
+	Year      int      `json:"year,omitempty"`
+	VIN       string   `json:"vin,omitempty"`
+	IDs       []string `json:"ids"`
+}
+
+// Comparison holds two cars and the attributes on which they differ. Each
+// difference lists the first car's value followed by the second car's.
+type Comparison struct {
+	Cars        [2]Car                    `json:"cars"`
+	Differences map[string][2]interface{} `json:"differences"`
+}
+
+// CarGroup holds the cars sharing a value of the grouping field
+type CarGroup struct {
+	Key  string `json:"key"`
+	Cars []Car  `json:"cars"`
+}
+
+// GroupedResult is a car listing grouped by one field
+type GroupedResult struct {
+	Groups []CarGroup `json:"groups"`
+}
+
+// Timeline intervals
+const (
+	IntervalDay   = "day"
+	IntervalWeek  = "week"
+	IntervalMonth = "month"
+)
+
+// ErrInvalidInterval is returned for timeline intervals other than day,
+// week and month
+var ErrInvalidInterval = errors.New("interval must be day, week or month")
+
+// timelineCacheTTL is how long timelines are cached. New cars show up once
+// it expires.
+const timelineCacheTTL = 30 * time.Second
+
+// TimelineBucket counts the cars created in the interval starting at Start
+type TimelineBucket struct {
+	Start timestamp.Time `json:"start"`
+	Count int            `json:"count"`
+}
+
+// Timeline counts the cars created per interval, with a bucket for every
+// interval in the requested range
+type Timeline struct {
+	Interval string           `json:"interval"`
+	Buckets  []TimelineBucket `json:"buckets"`
 }
 
 // Service handles car business logic
 type Service struct {
-	repo Repository
+	repo         Repository
+	idGenerator  IDGenerator
+	idGenerators map[string]IDGenerator
+	minYear      int
+	maxYear      int
+	cache        *cache.Cache
+	cacheTTL     time.Duration
+	writeThrough bool
+	valuator     Valuator
+}
+
+// DefaultMinYear is the earliest accepted model year, the year the first
+// automobile was built
+const DefaultMinYear = 1886
+
+// Option configures optional Service behavior
+type Option func(*Service)
+
+// WithIDGenerator makes the service assign IDs to cars created without one
+func WithIDGenerator(gen IDGenerator) Option {
+	return func(s *Service) {
+		s.idGenerator = gen
+	}
+}
+
+// WithTenantIDGenerator overrides the ID generator for a single tenant, so
+// tenants can opt into a different strategy than the default
+func WithTenantIDGenerator(tenantID string, gen IDGenerator) Option {
+	return func(s *Service) {
+		if s.idGenerators == nil {
+			s.idGenerators = make(map[string]IDGenerator)
+		}
+		s.idGenerators[tenantID] = gen
+	}
+}
+
+// WithYearBounds overrides the accepted model year range. A max of 0 keeps
+// the default of next year, evaluated whenever a car is validated.
+func WithYearBounds(minYear, maxYear int) Option {
+	return func(s *Service) {
+		s.minYear = minYear
+		s.maxYear = maxYear
+	}
+}
+
+// WithCache caches cars looked up by ID for ttl. Entries are dropped
+// whenever the service changes the car.
+func WithCache(c *cache.Cache, ttl time.Duration) Option {
+	return func(s *Service) {
+		s.cache = c
+		s.cacheTTL = ttl
+	}
+}
+
+// WithWriteThrough makes the service store cars it creates, updates or
+// restores in the cache instead of only invalidating them, so the next read
+// doesn't reach the repository. Bulk creates are not written through. It
+// has no effect without WithCache.
+func WithWriteThrough() Option {
+	return func(s *Service) {
+		s.writeThrough = true
+	}
+}
+
+// WithValuator replaces the default depreciation formula used to estimate
+// car values
+func WithValuator(v Valuator) Option {
+	return func(s *Service) {
+		s.valuator = v
+	}
 }
 
 // NewService creates a new car service
-func NewService(repo Repository) *Service {
-	return &Service{
-		repo: repo,
+func NewService(repo Repository, opts ...Option) *Service {
+	s := &Service{
+		repo:     repo,
+		minYear:  DefaultMinYear,
+		valuator: DepreciationValuator{},
 	}
+
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	return s
 }
 
-// GetCar retrieves a car by ID
-func (s *Service) GetCar(id string) (Car, error) {
-	return s.repo.Get(id)
+// GetCar retrieves a tenant's car by ID. Soft-deleted cars are not found.
+func (s *Service) GetCar(id, tenantID string) (Car, error) {
+	load := func() (interface{}, error) {
+		car, err := s.repo.Get(id, tenantID)
+		if err != nil {
+			return nil, err
+		}
+		if car.IsDeleted() {
+			return nil, ErrNotFound
+		}
+		return car, nil
+	}
+
+	// Fill under the car's lock so a write can't slip in between loading
+	// the car and caching it; see writeCar
+	var car interface{}
+	var err error
+	if s.cache == nil {
+		car, err = load()
+	} else {
+		car, err = s.cache.GetOrSet(cacheKey(id, tenantID), s.cacheTTL, load)
+	}
+	if err != nil {
+		return Car{}, err
+	}
+	return car.(Car), nil
 }
 
-// GetAllCars retrieves all cars
-func (s *Service) GetAllCars() []Car {
-	return s.repo.GetAll()
+// GetAllCars retrieves all of a tenant's cars that haven't been soft-deleted
+func (s *Service) GetAllCars(tenantID string) []Car {
+	return excludeDeleted(s.repo.GetAll(tenantID))
 }
 
-// GetFilteredCars retrieves cars with filtering and sorting
-func (s *Service) GetFilteredCars(filter FilterOptions, sort *SortOptions) []Car {
+// GetFilteredCars retrieves a tenant's cars with filtering and sorting
+func (s *Service) GetFilteredCars(tenantID string, filter FilterOptions, sort []SortOptions) []Car {
 	// Get all cars
-	cars := s.repo.GetAll()
+	cars := s.repo.GetAll(tenantID)
+	if !filter.IncludeDeleted {
+		cars = excludeDeleted(cars)
+	}
 
 	// Apply filters
 	cars = applyFilters(cars, filter)
 
 	// Apply sorting if requested
-	if sort != nil && sort.Field != "" {
-		cars = applySorting(cars, *sort)
+	if len(sort) > 0 {
+		cars = applySorting(cars, sort)
+	}
+
+	// Order by closeness to the target year, keeping the sort for ties
+	if filter.NearYear != 0 {
+		cars = applyNearYear(cars, filter.NearYear)
 	}
 
 	return cars
 }
 
-// GetPagedCars retrieves cars with filtering, sorting, and pagination
-func (s *Service) GetPagedCars(filter FilterOptions, sort *SortOptions, pagination PaginationOptions) PagedResult {
+// GetGroupedCars retrieves a tenant's filtered cars grouped by make, model
+// or year. Text fields are grouped ignoring case, and a group's key is the
+// value as written on its first car. Cars keep the requested sort within
+// each group, and groups are ordered by key, descending when the sort
+// orders the grouping field that way.
+func (s *Service) GetGroupedCars(tenantID string, filter FilterOptions, sort []SortOptions, field string) GroupedResult {
+	cars := s.GetFilteredCars(tenantID, filter, sort)
+
+	groups := []CarGroup{}
+	index := make(map[string]int)
+	for _, car := range cars {
+		var value string
+		switch field {
+		case "make":
+			value = car.Make
+		case "model":
+			value = car.Model
+		default:
+			value = strconv.Itoa(car.Year)
+		}
+
+		key := strings.ToLower(value)
+		i, ok := index[key]
+		if !ok {
+			i = len(groups)
+			index[key] = i
+			groups = append(groups, CarGroup{Key: value})
+		}
+		groups[i].Cars = append(groups[i].Cars, car)
+	}
+
+	descending := false
+	for _, opt := range sort {
+		if opt.Field == field {
+			descending = strings.ToLower(opt.Order) == "desc"
+			break
+		}
+	}
+	slices.SortStableFunc(groups, func(a, b CarGroup) int {
+		c := compareCarField(a.Cars[0], b.Cars[0], field)
+		if descending {
+			return -c
+		}
+		return c
+	})
+
+	return GroupedResult{Groups: groups}
+}
+
+// CountCars returns how many of a tenant's cars match the filter, without
+// sorting or paginating them
+func (s *Service) CountCars(tenantID string, filter FilterOptions) int {
+	cars := s.repo.GetAll(tenantID)
+	if !filter.IncludeDeleted {
+		cars = excludeDeleted(cars)
+	}
+	return len(applyFilters(cars, filter))
+}
+
+// GetPagedCars retrieves a tenant's cars with filtering, sorting, and pagination
+func (s *Service) GetPagedCars(tenantID string, filter FilterOptions, sort []SortOptions, params PaginationOptions) PagedResult {
 	// Get filtered and sorted cars
-	filteredCars := s.GetFilteredCars(filter, sort)
+	filteredCars := s.GetFilteredCars(tenantID, filter, sort)
+
+	return PagedResult{
+		Result: pagination.Paginate(filteredCars, params),
+	}
+}
 
-	// Total items and pages
-	totalItems := len(filteredCars)
+// FindDuplicates groups a tenant's cars sharing the same make, model and
+// year, or the same VIN, and returns every group containing two or more
+// cars. Make and model compare case-insensitively and each group shows the
+// spelling of its car with the lowest ID; cars without a VIN are only
+// grouped by make, model and year. A car can appear in one group of each
+// kind.
+func (s *Service) FindDuplicates(tenantID string) []DuplicateGroup {
+	cars := s.GetAllCars(tenantID)
+	slices.SortFunc(cars, func(a, b Car) int { return cmp.Compare(a.ID, b.ID) })
 
-	// Default pagination values if not set
-	if pagination.Page < 1 {
-		pagination.Page = 1
+	result := []DuplicateGroup{}
+	result = appendDuplicates(result, cars, func(car Car) (string, DuplicateGroup) {
+		key := strings.ToLower(car.Make) + "|" + strings.ToLower(car.Model) + "|" + strconv.Itoa(car.Year)
+		return key, DuplicateGroup{Criterion: DuplicateByMakeModelYear, Make: car.Make, Model: car.Model, Year: car.Year}
+	})
+	result = appendDuplicates(result, cars, func(car Car) (string, DuplicateGroup) {
+		vin := normalizeVIN(car.VIN)
+		return vin, DuplicateGroup{Criterion: DuplicateByVIN, VIN: vin}
+	})
+
+	return result
+}
+
+// appendDuplicates groups cars by the key returned by groupOf, skipping
+// empty keys, and appends every group of two or more cars to result. The
+// group returned for a group's first car describes the whole group.
+func appendDuplicates(result []DuplicateGroup, cars []Car, groupOf func(Car) (string, DuplicateGroup)) []DuplicateGroup {
+	groups := make(map[string]*DuplicateGroup)
+	var keys []string
+
+	for _, car := range cars {
+		key, candidate := groupOf(car)
+		if key == "" {
+			continue
+		}
+		group, exists := groups[key]
+		if !exists {
+			group = &candidate
+			groups[key] = group
+			keys = append(keys, key)
+		}
+		group.IDs = append(group.IDs, car.ID)
 	}
 
-	if pagination.PageSize < 1 {
-		pagination.PageSize = 10
+	// Keep the output stable regardless of map iteration order
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		group := groups[key]
+		if len(group.IDs) < 2 {
+			continue
+		}
+		result = append(result, *group)
 	}
 
-	// Calculate total pages
-	totalPages := (totalItems + pagination.PageSize - 1) / pagination.PageSize
-	if totalPages == 0 {
-		totalPages = 1
+	return result
+}
+
+// GetTimeline counts the tenant's cars created per day, week or month
+// between from and to, inclusive. Buckets start at midnight UTC, weeks on
+// Monday and months on the 1st; the range is widened to whole buckets.
+// Soft-deleted cars aren't counted. Results are cached briefly when the
+// service has a cache.
+func (s *Service) GetTimeline(tenantID, interval string, from, to time.Time) (Timeline, error) {
+	first, err := truncateToInterval(from, interval)
+	if err != nil {
+		return Timeline{}, err
 	}
+	last, _ := truncateToInterval(to, interval)
 
-	// Ensure page is within bounds
-	if pagination.Page > totalPages {
-		pagination.Page = totalPages
+	compute := func() (interface{}, error) {
+		return s.computeTimeline(tenantID, interval, first, last), nil
+	}
+	if s.cache == nil {
+		timeline, _ := compute()
+		return timeline.(Timeline), nil
 	}
 
-	// Calculate start and end indices
-	startIndex := (pagination.Page - 1) * pagination.PageSize
-	endIndex := startIndex + pagination.PageSize
+	// Key on the bucket bounds, so requests for the same buckets, such as
+	// every default request made during one day, share an entry
+	key := "timeline:" + tenantID + ":" + interval + ":" + first.Format(time.DateOnly) + ":" + last.Format(time.DateOnly)
+	timeline, err := s.cache.GetOrSet(key, timelineCacheTTL, compute)
+	if err != nil {
+		return Timeline{}, err
+	}
+	return timeline.(Timeline), nil
+}
+
+// computeTimeline buckets the tenant's cars for GetTimeline. The interval
+// must already be valid.
+func (s *Service) computeTimeline(tenantID, interval string, from, to time.Time) Timeline {
+	first, _ := truncateToInterval(from, interval)
+	last, _ := truncateToInterval(to, interval)
 
-	// Ensure end index doesn't exceed array bounds
-	if endIndex > totalItems {
-		endIndex = totalItems
+	var buckets []TimelineBucket
+	index := make(map[time.Time]int)
+	for start := first; !start.After(last); start = nextInterval(start, interval) {
+		index[start] = len(buckets)
+		buckets = append(buckets, TimelineBucket{Start: timestamp.New(start)})
 	}
 
-	// Get the slice of cars for the current page
-	var pagedCars []Car
-	if startIndex < totalItems {
-		pagedCars = filteredCars[startIndex:endIndex]
-	} else {
-		pagedCars = []Car{}
+	for _, car := range s.GetAllCars(tenantID) {
+		start, _ := truncateToInterval(car.CreatedAt.Time, interval)
+		if i, ok := index[start]; ok {
+			buckets[i].Count++
+		}
 	}
 
-	return PagedResult{
-		Data:       pagedCars,
-		TotalItems: totalItems,
-		TotalPages: totalPages,
-		Page:       pagination.Page,
-		PageSize:   pagination.PageSize,
+	return Timeline{
+		Interval: interval,
+		Buckets:  buckets,
+	}
+}
+
+// timelineBucketCount returns how many buckets a timeline between from and
+// to would have, so callers can bound the range
+func timelineBucketCount(interval string, from, to time.Time) (int, error) {
+	first, err := truncateToInterval(from, interval)
+	if err != nil {
+		return 0, err
+	}
+	last, _ := truncateToInterval(to, interval)
+
+	switch interval {
+	case IntervalMonth:
+		return (last.Year()-first.Year())*12 + int(last.Month()-first.Month()) + 1, nil
+	case IntervalWeek:
+		return int(last.Sub(first).Hours()/(24*7)) + 1, nil
+	default:
+		return int(last.Sub(first).Hours()/24) + 1, nil
+	}
+}
+
+// truncateToInterval returns the start of the UTC day, ISO week or month
+// containing t
+func truncateToInterval(t time.Time, interval string) (time.Time, error) {
+	t = t.UTC()
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+
+	switch interval {
+	case IntervalDay:
+		return day, nil
+	case IntervalWeek:
+		// Weeks start on Monday
+		offset := (int(day.Weekday()) + 6) % 7
+		return day.AddDate(0, 0, -offset), nil
+	case IntervalMonth:
+		return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC), nil
+	}
+	return time.Time{}, ErrInvalidInterval
+}
+
+// nextInterval returns the start of the interval after the one starting at
+// start
+func nextInterval(start time.Time, interval string) time.Time {
+	switch interval {
+	case IntervalWeek:
+		return start.AddDate(0, 0, 7)
+	case IntervalMonth:
+		return start.AddDate(0, 1, 0)
+	}
+	return start.AddDate(0, 0, 1)
+}
+
+// GetCarByVIN retrieves a tenant's car by VIN, ignoring case. Soft-deleted
+// cars are not found.
+func (s *Service) GetCarByVIN(vin, tenantID string) (Car, error) {
+	vin = normalizeVIN(vin)
+	if err := validateVIN(vin); err != nil {
+		return Car{}, err
+	}
+
+	car, err := s.repo.GetByVIN(vin, tenantID)
+	if err != nil {
+		return Car{}, err
+	}
+	if car.IsDeleted() {
+		return Car{}, ErrNotFound
 	}
+	return car, nil
+}
+
+// CompareCars fetches two of a tenant's cars and reports which attributes
+// differ between them
+func (s *Service) CompareCars(tenantID, firstID, secondID string) (Comparison, error) {
+	first, err := s.GetCar(firstID, tenantID)
+	if err != nil {
+		return Comparison{}, err
+	}
+	second, err := s.GetCar(secondID, tenantID)
+	if err != nil {
+		return Comparison{}, err
+	}
+
+	differences := make(map[string][2]interface{})
+	if first.Make != second.Make {
+		differences["make"] = [2]interface{}{first.Make, second.Make}
+	}
+	if first.Model != second.Model {
+		differences["model"] = [2]interface{}{first.Model, second.Model}
+	}
+	if first.Year != second.Year {
+		differences["year"] = [2]interface{}{first.Year, second.Year}
+	}
+	if first.Color != second.Color {
+		differences["color"] = [2]interface{}{first.Color, second.Color}
+	}
+
+	return Comparison{
+		Cars:        [2]Car{first, second},
+		Differences: differences,
+	}, nil
 }
 
 // CreateCar creates a new car, validating the data
 func (s *Service) CreateCar(car Car) (Car, error) {
-	if err := validateCar(car); err != nil {
+	return s.createCar(car, s.writeThrough)
+}
+
+// createCar creates a car, caching it when writeThrough is set. Bulk
+// creates don't write through so that one import can't evict the whole
+// working set from the cache.
+func (s *Service) createCar(car Car, writeThrough bool) (Car, error) {
+	generated := car.ID == ""
+	car, err := s.prepareCar(car, false)
+	if err != nil {
 		return Car{}, err
 	}
+	car.CreatedAt = timestamp.Now()
+	car.DeletedAt = nil
 
-	return s.repo.Create(car)
+	for attempt := 1; ; attempt++ {
+		stored, err := s.writeCar(car.ID, car.TenantID, writeThrough, func() (Car, error) {
+			return s.repo.Create(car)
+		})
+
+		// A generated ID can collide with one a client supplied, such as
+		// CAR-0003 in a sequence; move on to the next ID
+		if generated && errors.Is(err, ErrConflict) && attempt < maxIDAttempts {
+			if car.ID, err = s.idGeneratorFor(car.TenantID).NextID(car.TenantID); err != nil {
+				return Car{}, err
+			}
+			continue
+		}
+		return stored, err
+	}
 }
 
-// UpdateCar updates an existing car, validating the data
+// maxIDAttempts bounds how many generated IDs a create tries before
+// reporting the conflict
+const maxIDAttempts = 100
+
+// prepareCar normalizes and validates a car about to be created, assigning
+// an ID when the client didn't provide one and a generator is set. A dry
+// run doesn't take an ID from the generator: a generated ID can't be
+// invalid, so the rest of the car is validated with a stand-in and the ID
+// is left empty.
+func (s *Service) prepareCar(car Car, dryRun bool) (Car, error) {
+	car.VIN = normalizeVIN(car.VIN)
+
+	gen := s.idGeneratorFor(car.TenantID)
+	generated := car.ID == "" && gen != nil
+	if generated {
+		if dryRun {
+			car.ID = "generated"
+		} else {
+			id, err := gen.NextID(car.TenantID)
+			if err != nil {
+				return Car{}, err
+			}
+			car.ID = id
+		}
+	}
+
+	if err := s.validateCar(car); err != nil {
+		return Car{}, err
+	}
+
+	if generated && dryRun {
+		car.ID = ""
+	}
+	return car, nil
+}
+
+// idGeneratorFor returns the tenant's ID generator, falling back to the default
+func (s *Service) idGeneratorFor(tenantID string) IDGenerator {
+	if gen, ok := s.idGenerators[tenantID]; ok {
+		return gen
+	}
+	return s.idGenerator
+}
+
+// CreateCars creates each car under the tenant independently so one failure
+// doesn't abort the rest, reporting the outcome per item in input order
+func (s *Service) CreateCars(tenantID string, cars []Car) BatchResult[Car] {
+	return s.CreateCarsWithProgress(tenantID, cars, nil)
+}
+
+// CreateCarsWithProgress is like CreateCars but calls progress, if set,
+// after each car with the number of cars processed and failed so far
+func (s *Service) CreateCarsWithProgress(tenantID string, cars []Car, progress func(processed, failed int)) BatchResult[Car] {
+	result := newBatchResult[Car](len(cars))
+
+	for i, input := range cars {
+		car := input
+		car.TenantID = tenantID
+
+		if created, err := s.createCar(car, false); err != nil {
+			result.fail(i, input, err)
+		} else {
+			result.succeed(created)
+		}
+
+		if progress != nil {
+			progress(i+1, result.Summary.Failed)
+		}
+	}
+
+	return result
+}
+
+// ImportRow is one row of an import. Err is set when the row couldn't be
+// read into a car, such as a CSV row whose year isn't a number; the row is
+// then reported as failed without being validated or created.
+type ImportRow struct {
+	Car Car
+	Err error
+}
+
+// ImportCars validates every row and creates the valid ones under the
+// tenant, reporting each rejected row. With dryRun set nothing is written;
+// the result reports what a real import would create and reject, including
+// conflicts with existing cars and between rows of the import.
+func (s *Service) ImportCars(tenantID string, rows []ImportRow, dryRun bool) BatchResult[Car] {
+	result := newBatchResult[Car](len(rows))
+	result.DryRun = dryRun
+
+	seenIDs := make(map[string]bool)
+	seenVINs := make(map[string]bool)
+
+	for i, row := range rows {
+		if row.Err != nil {
+			result.fail(i, row.Car, row.Err)
+			continue
+		}
+
+		car := row.Car
+		car.TenantID = tenantID
+
+		var err error
+		if dryRun {
+			car, err = s.checkImportCar(car, seenIDs, seenVINs)
+		} else {
+			car, err = s.createCar(car, false)
+		}
+
+		if err != nil {
+			result.fail(i, row.Car, err)
+			continue
+		}
+		result.succeed(car)
+	}
+
+	return result
+}
+
+// checkImportCar returns the car as CreateCar would accept it, without
+// writing it. seenIDs and seenVINs track the rows accepted so far so that
+// conflicts within the import are caught too.
+func (s *Service) checkImportCar(car Car, seenIDs, seenVINs map[string]bool) (Car, error) {
+	car, err := s.prepareCar(car, true)
+	if err != nil {
+		return Car{}, err
+	}
+
+	// An empty ID will be generated, so it can't conflict
+	if car.ID != "" {
+		if existing, err := s.repo.Get(car.ID, car.TenantID); (err == nil && !existing.IsDeleted()) || seenIDs[car.ID] {
+			return Car{}, asConflict(ErrConflict)
+		}
+	}
+	if car.VIN != "" {
+		if _, err := s.repo.GetByVIN(car.VIN, car.TenantID); err == nil || seenVINs[car.VIN] {
+			return Car{}, asConflict(ErrDuplicateVIN)
+		}
+	}
+
+	if car.ID != "" {
+		seenIDs[car.ID] = true
+	}
+	if car.VIN != "" {
+		seenVINs[car.VIN] = true
+	}
+	return car, nil
+}
+
+// UpdateCar updates an existing car, validating the data. Soft-deleted cars
+// must be restored before they can be updated.
 func (s *Service) UpdateCar(car Car) (Car, error) {
-	if err := validateCar(car); err != nil {
+	car.VIN = normalizeVIN(car.VIN)
+	if err := s.validateCar(car); err != nil {
+		return Car{}, err
+	}
+
+	existing, err := s.GetCar(car.ID, car.TenantID)
+	if err != nil {
+		return Car{}, err
+	}
+	car.CreatedAt = existing.CreatedAt
+	car.DeletedAt = nil
+
+	return s.writeCar(car.ID, car.TenantID, s.writeThrough, func() (Car, error) {
+		return s.repo.Update(car)
+	})
+}
+
+// PatchCar applies a partial update to a tenant's existing car, validating
+// the merged result
+func (s *Service) PatchCar(id, tenantID string, patch CarPatch) (Car, error) {
+	existing, err := s.GetCar(id, tenantID)
+	if err != nil {
 		return Car{}, err
 	}
 
-	return s.repo.Update(car)
+	return s.UpdateCar(patch.Apply(existing))
 }
 
-// DeleteCar deletes a car by ID
-func (s *Service) DeleteCar(id string) error {
-	return s.repo.Delete(id)
+// DeleteCar soft-deletes a tenant's car by ID, hiding it until it is restored
+func (s *Service) DeleteCar(id, tenantID string) error {
+	car, err := s.GetCar(id, tenantID)
+	if err != nil {
+		return err
+	}
+
+	now := timestamp.Now()
+	car.DeletedAt = &now
+	_, err = s.writeCar(id, tenantID, false, func() (Car, error) {
+		return s.repo.Update(car)
+	})
+	return err
 }
 
-// validateCar checks if car data is valid
-func validateCar(car Car) error {
+// RestoreCar undoes a soft delete. Restoring a car that isn't deleted is a
+// no-op that returns the car.
+func (s *Service) RestoreCar(id, tenantID string) (Car, error) {
+	car, err := s.repo.Get(id, tenantID)
+	if err != nil {
+		return Car{}, err
+	}
+	if !car.IsDeleted() {
+		return car, nil
+	}
+
+	car.DeletedAt = nil
+	return s.writeCar(id, tenantID, s.writeThrough, func() (Car, error) {
+		return s.repo.Update(car)
+	})
+}
+
+// cacheKey returns the cache key for a tenant's car
+func cacheKey(id, tenantID string) string {
+	return "car:" + tenantID + ":" + id
+}
+
+// invalidate drops a tenant's car and today's valuation of it from the
+// cache after it changed
+func (s *Service) invalidate(id, tenantID string) {
+	if s.cache != nil {
+		s.cache.Delete(cacheKey(id, tenantID))
+		s.cache.Delete(valuationKey(id, tenantID, time.Now().UTC().Format(time.DateOnly)))
+	}
+}
+
+// writeCar runs write while holding the car's cache lock, then drops the
+// cached copy and, with cacheResult set, caches the car the write stored.
+// Conflicts are returned as a ConflictError.
+// GetCar fills under the same lock, so a reader can't cache a car loaded
+// before the write, and concurrent writers update the cache in the order
+// they wrote.
+func (s *Service) writeCar(id, tenantID string, cacheResult bool, write func() (Car, error)) (Car, error) {
+	if s.cache == nil {
+		stored, err := write()
+		return stored, asConflict(err)
+	}
+
+	unlock := s.cache.Lock(cacheKey(id, tenantID))
+	defer unlock()
+
+	stored, err := write()
+	s.invalidate(id, tenantID)
+	if err == nil && cacheResult {
+		s.cache.Set(cacheKey(id, tenantID), stored, s.cacheTTL)
+	}
+	return stored, asConflict(err)
+}
+
+// errorField returns the car field an error refers to, if any
+func errorField(err error) string {
+	var validationErr *ValidationError
+	if errors.As(err, &validationErr) {
+		return validationErr.Field
+	}
+	var conflictErr *ConflictError
+	if errors.As(err, &conflictErr) {
+		return conflictErr.Field
+	}
+	return ""
+}
+
+// errorCode returns the API error code for an error from the service
+func errorCode(err error) apierror.Code {
+	var validationErr *ValidationError
+	var conflictErr *ConflictError
+	switch {
+	case errors.As(err, &conflictErr):
+		return apierror.Conflict
+	case errors.Is(err, ErrNotFound):
+		return apierror.CarNotFound
+	case errors.As(err, &validationErr), errors.Is(err, ErrInvalidCar):
+		return apierror.ValidationFailed
+	default:
+		return apierror.InternalError
+	}
+}
+
+// validateCar checks car against the service's year bounds
+func (s *Service) validateCar(car Car) error {
+	minYear, maxYear := s.yearBounds(time.Now())
+	return validateCar(car, minYear, maxYear)
+}
+
+// yearBounds returns the accepted model year range at now. Unless
+// overridden, the maximum is next year so next-model-year cars are accepted.
+func (s *Service) yearBounds(now time.Time) (int, int) {
+	maxYear := s.maxYear
+	if maxYear == 0 {
+		maxYear = now.Year() + 1
+	}
+	return s.minYear, maxYear
+}
+
+// validateCar checks if car data is valid, with the model year between
+// minYear and maxYear inclusive
+func validateCar(car Car, minYear, maxYear int) error {
 	// ID must be present and in a valid format
 	if car.ID == "" {
-		return errors.New("ID is required")
+		return &ValidationError{Field: "id", Message: "ID is required"}
 	}
 
 	// ID should be alphanumeric, allow dashes and underscores
-	match, _ := regexp.MatchString(`^[a-zA-Z0-9_-]+$`, car.ID)
-	if !match {
-		return errors.New("ID must be alphanumeric, dashes and underscores allowed")
+	if !idPattern.MatchString(car.ID) {
+		return &ValidationError{Field: "id", Message: "ID must be alphanumeric, dashes and underscores allowed"}
 	}
 
 	// Make must be present
 	if car.Make == "" {
-		return errors.New("make is required")
+		return &ValidationError{Field: "make", Message: "make is required"}
 	}
 
 	// Model must be present
 	if car.Model == "" {
-		return errors.New("model is required")
+		return &ValidationError{Field: "model", Message: "model is required"}
 	}
 
 	// Year validation
-	if car.Year < 1886 || car.Year > 3000 {
-		return errors.New("year must be between 1886 and 3000")
+	if car.Year < minYear || car.Year > maxYear {
+		return &ValidationError{Field: "year", Message: fmt.Sprintf("year must be between %d and %d", minYear, maxYear)}
 	}
 
 	// Color is optional, but should be valid if provided
 	if car.Color != "" {
-		match, _ = regexp.MatchString(`^[a-zA-Z0-9 ]+$`, car.Color)
+		match, _ := regexp.MatchString(`^[a-zA-Z0-9 ]+$`, car.Color)
 		if !match {
-			return errors.New("color must be alphanumeric")
+			return &ValidationError{Field: "color", Message: "color must be alphanumeric"}
+		}
+	}
+
+	// VIN is optional, but must be a valid VIN if provided
+	if car.VIN != "" {
+		if err := validateVIN(car.VIN); err != nil {
+			return err
 		}
 	}
 
 	return nil
 }
 
+// vinWeights are the ISO 3779 position weights used for the check digit
+var vinWeights = [17]int{8, 7, 6, 5, 4, 3, 2, 10, 0, 9, 8, 7, 6, 5, 4, 3, 2}
+
+// normalizeVIN trims a VIN and upper-cases it
+func normalizeVIN(vin string) string {
+	return strings.ToUpper(strings.TrimSpace(vin))
+}
+
+// validateVIN checks that an upper-case VIN has 17 characters, doesn't use
+// I, O or Q, and carries a correct check digit in position 9
+func validateVIN(vin string) error {
+	if len(vin) != 17 {
+		return &ValidationError{Field: "vin", Message: "VIN must be 17 characters"}
+	}
+
+	sum := 0
+	for i := 0; i < len(vin); i++ {
+		value, ok := vinValue(vin[i])
+		if !ok {
+			return &ValidationError{Field: "vin", Message: "VIN may only contain digits and letters other than I, O and Q"}
+		}
+		sum += value * vinWeights[i]
+	}
+
+	check := byte('0' + sum%11)
+	if sum%11 == 10 {
+		check = 'X'
+	}
+	if vin[8] != check {
+		return &ValidationError{Field: "vin", Message: "VIN check digit is invalid"}
+	}
+
+	return nil
+}
+
+// vinValue transliterates a VIN character to its numeric value
+func vinValue(c byte) (int, bool) {
+	switch {
+	case c >= '0' && c <= '9':
+		return int(c - '0'), true
+	case c >= 'A' && c <= 'H':
+		return int(c-'A') + 1, true
+	case c >= 'J' && c <= 'N':
+		return int(c-'J') + 1, true
+	case c == 'P':
+		return 7, true
+	case c == 'R':
+		return 9, true
+	case c >= 'S' && c <= 'Z':
+		return int(c-'S') + 2, true
+	}
+	return 0, false
+}
+
 // applyFilters filters the cars based on filter options
 func applyFilters(cars []Car, filter FilterOptions) []Car {
 	var result []Car
 
 	for _, car := range cars {
 		// Check all filters
-		if (filter.Make == "" || strings.EqualFold(car.Make, filter.Make)) &&
-			(filter.Model == "" || strings.EqualFold(car.Model, filter.Model)) &&
+		if (filter.Make == "" || matchText(car.Make, filter.Make, filter.Match)) &&
+			(filter.Model == "" || matchText(car.Model, filter.Model, filter.Match)) &&
 			(filter.Year == 0 || car.Year == filter.Year) &&
-			(filter.Color == "" || strings.EqualFold(car.Color, filter.Color)) {
+			(filter.Color == "" || matchText(car.Color, filter.Color, filter.Match)) &&
+			(filter.MakePrefix == "" || hasPrefixFold(car.Make, filter.MakePrefix)) &&
+			(filter.ModelPrefix == "" || hasPrefixFold(car.Model, filter.ModelPrefix)) {
 			result = append(result, car)
 		}
 	}
@@ -214,52 +1062,89 @@ func applyFilters(cars []Car, filter FilterOptions) []Car {
 	return result
 }
 
-// applySorting sorts the cars based on sort options
-func applySorting(cars []Car, sortOpt SortOptions) []Car {
+// matchText compares a car field with a filter value using the match mode,
+// ignoring case
+func matchText(value, filter, mode string) bool {
+	if mode == MatchContains {
+		return strings.Contains(strings.ToLower(value), strings.ToLower(filter))
+	}
+	return strings.EqualFold(value, filter)
+}
+
+// hasPrefixFold reports whether s begins with prefix, ignoring case
+func hasPrefixFold(s, prefix string) bool {
+	return strings.HasPrefix(strings.ToLower(s), strings.ToLower(prefix))
+}
+
+// applyNearYear orders cars by absolute distance from the target year
+func applyNearYear(cars []Car, year int) []Car {
+	result := make([]Car, len(cars))
+	copy(result, cars)
+
+	distance := func(c Car) int {
+		if c.Year > year {
+			return c.Year - year
+		}
+		return year - c.Year
+	}
+
+	sort.SliceStable(result, func(i, j int) bool {
+		return distance(result[i]) < distance(result[j])
+	})
+
+	return result
+}
+
+// excludeDeleted returns the cars that haven't been soft-deleted
+func excludeDeleted(cars []Car) []Car {
+	result := make([]Car, 0, len(cars))
+	for _, car := range cars {
+		if !car.IsDeleted() {
+			result = append(result, car)
+		}
+	}
+	return result
+}
+
+// applySorting stably sorts the cars by each sort option in turn, so later
+// options only break ties left by earlier ones
+func applySorting(cars []Car, sortOpts []SortOptions) []Car {
 	result := make([]Car, len(cars))
 	copy(result, cars)
 
-	// Determine sort order
-	isAscending := sortOpt.Order == "" || strings.ToLower(sortOpt.Order) == "asc"
+	sort.SliceStable(result, func(i, j int) bool {
+		for _, opt := range sortOpts {
+			c := compareCarField(result[i], result[j], opt.Field)
+			if c == 0 {
+				continue
+			}
+			if strings.ToLower(opt.Order) == "desc" {
+				return c > 0
+			}
+			return c < 0
+		}
+		return false
+	})
+
+	return result
+}
 
-	// Sort based on field
-	switch strings.ToLower(sortOpt.Field) {
+// compareCarField compares two cars on a single field, ignoring case for
+// text fields. Unknown fields compare as equal.
+func compareCarField(a, b Car, field string) int {
+	switch strings.ToLower(field) {
 	case "make":
-		sort.Slice(result, func(i, j int) bool {
-			if isAscending {
-				return strings.ToLower(result[i].Make) < strings.ToLower(result[j].Make)
-			}
-			return strings.ToLower(result[i].Make) > strings.ToLower(result[j].Make)
-		})
+		return strings.Compare(strings.ToLower(a.Make), strings.ToLower(b.Make))
 	case "model":
-		sort.Slice(result, func(i, j int) bool {
-			if isAscending {
-				return strings.ToLower(result[i].Model) < strings.ToLower(result[j].Model)
-			}
-			return strings.ToLower(result[i].Model) > strings.ToLower(result[j].Model)
-		})
+		return strings.Compare(strings.ToLower(a.Model), strings.ToLower(b.Model))
 	case "year":
-		sort.Slice(result, func(i, j int) bool {
-			if isAscending {
-				return result[i].Year < result[j].Year
-			}
-			return result[i].Year > result[j].Year
-		})
+		return cmp.Compare(a.Year, b.Year)
 	case "color":
-		sort.Slice(result, func(i, j int) bool {
-			if isAscending {
-				return strings.ToLower(result[i].Color) < strings.ToLower(result[j].Color)
-			}
-			return strings.ToLower(result[i].Color) > strings.ToLower(result[j].Color)
-		})
+		return strings.Compare(strings.ToLower(a.Color), strings.ToLower(b.Color))
 	case "id":
-		sort.Slice(result, func(i, j int) bool {
-			if isAscending {
-				return strings.ToLower(result[i].ID) < strings.ToLower(result[j].ID)
-			}
-			return strings.ToLower(result[i].ID) > strings.ToLower(result[j].ID)
-		})
+		return strings.Compare(strings.ToLower(a.ID), strings.ToLower(b.ID))
+	case "created_at":
+		return a.CreatedAt.Compare(b.CreatedAt.Time)
 	}
-
-	return result
+	return 0
 }
@@ -1,8 +1,20 @@
 package car
 
 import (
+	"errors"
+	"reflect"
+	"regexp"
+	"slices"
+	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
+
+	"github.com/joshbarros/golang-carflow-api/internal/apierror"
+	"github.com/joshbarros/golang-carflow-api/internal/cache"
+	"github.com/joshbarros/golang-carflow-api/internal/timestamp"
 )
 
 func TestValidateCar(t *testing.T) {
@@ -49,7 +61,7 @@ func TestValidateCar(t *testing.T) {
 		},
 		{
 			name:    "Year too new",
-			car:     Car{ID: "test1", Make: "Toyota", Model: "Corolla", Year: 3001, Color: "blue"},
+			car:     Car{ID: "test1", Make: "Toyota", Model: "Corolla", Year: 2031, Color: "blue"},
 			wantErr: true,
 			errMsg:  "year must be between",
 		},
@@ -63,7 +75,7 @@ func TestValidateCar(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			err := validateCar(tt.car)
+			err := validateCar(tt.car, DefaultMinYear, 2030)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("validateCar() error = %v, wantErr %v", err, tt.wantErr)
 				return
@@ -75,6 +87,40 @@ func TestValidateCar(t *testing.T) {
 	}
 }
 
+func TestService_YearBounds(t *testing.T) {
+	nextYear := time.Now().Year() + 1
+
+	tests := []struct {
+		name    string
+		opts    []Option
+		year    int
+		wantErr bool
+	}{
+		{"next model year", nil, nextYear, false},
+		{"beyond next year", nil, nextYear + 1, true},
+		{"typo", nil, 20200, true},
+		{"first automobile", nil, DefaultMinYear, false},
+		{"before first automobile", nil, DefaultMinYear - 1, true},
+		{"custom max", []Option{WithYearBounds(1950, 2000)}, 2001, true},
+		{"custom min", []Option{WithYearBounds(1950, 0)}, 1949, true},
+		{"custom min keeps default max", []Option{WithYearBounds(1950, 0)}, nextYear, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := NewService(NewInMemoryRepository(), tt.opts...)
+			_, err := service.CreateCar(Car{ID: "c1", Make: "Ford", Model: "Model T", Year: tt.year})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("CreateCar() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			var verr *ValidationError
+			if tt.wantErr && (!errors.As(err, &verr) || verr.Field != "year") {
+				t.Errorf("CreateCar() error = %v, want year field error", err)
+			}
+		})
+	}
+}
+
 func TestService_GetCar(t *testing.T) {
 	repo := NewInMemoryRepository()
 	service := NewService(repo)
@@ -84,7 +130,7 @@ func TestService_GetCar(t *testing.T) {
 	repo.Create(testCar)
 
 	// Test retrieval
-	car, err := service.GetCar("service-test-1")
+	car, err := service.GetCar("service-test-1", "")
 	if err != nil {
 		t.Errorf("GetCar() error = %v", err)
 	}
@@ -93,7 +139,7 @@ func TestService_GetCar(t *testing.T) {
 	}
 
 	// Test error case
-	_, err = service.GetCar("nonexistent")
+	_, err = service.GetCar("nonexistent", "")
 	if err != ErrNotFound {
 		t.Errorf("GetCar() error = %v, want %v", err, ErrNotFound)
 	}
@@ -104,7 +150,7 @@ func TestService_GetAllCars(t *testing.T) {
 	service := NewService(repo)
 
 	// Empty repository
-	cars := service.GetAllCars()
+	cars := service.GetAllCars("")
 	if len(cars) != 0 {
 		t.Errorf("GetAllCars() = %v, want empty slice", cars)
 	}
@@ -114,7 +160,7 @@ func TestService_GetAllCars(t *testing.T) {
 	repo.Create(Car{ID: "all-2", Make: "Nissan", Model: "Altima", Year: 2020, Color: "white"})
 
 	// Test retrieval
-	cars = service.GetAllCars()
+	cars = service.GetAllCars("")
 	if len(cars) != 2 {
 		t.Errorf("GetAllCars() = %v, want 2 cars", len(cars))
 	}
@@ -170,3 +216,921 @@ func TestService_UpdateCar(t *testing.T) {
 		t.Errorf("UpdateCar() error = %v, want %v", err, ErrNotFound)
 	}
 }
+
+func TestService_FindDuplicates(t *testing.T) {
+	repo := NewInMemoryRepository()
+	service := NewService(repo)
+
+	// The repository compares VINs exactly, so differently written VINs
+	// stored directly slip past its duplicate check
+	repo.Create(Car{ID: "dup-1", Make: "Toyota", Model: "Corolla", Year: 2020, Color: "blue"})
+	repo.Create(Car{ID: "dup-2", Make: "toyota", Model: "corolla", Year: 2020, Color: "red"})
+	repo.Create(Car{ID: "dup-3", Make: "Toyota", Model: "Corolla", Year: 2021, Color: "blue", VIN: "1hgcm82633a004352"})
+	repo.Create(Car{ID: "dup-4", Make: "Honda", Model: "Civic", Year: 2019, Color: "red", VIN: " 1HGCM82633A004352"})
+	repo.Create(Car{ID: "dup-5", Make: "Honda", Model: "Accord", Year: 2018, Color: "red"})
+
+	want := []DuplicateGroup{
+		{Criterion: DuplicateByMakeModelYear, Make: "Toyota", Model: "Corolla", Year: 2020, IDs: []string{"dup-1", "dup-2"}},
+		{Criterion: DuplicateByVIN, VIN: "1HGCM82633A004352", IDs: []string{"dup-3", "dup-4"}},
+	}
+
+	groups := service.FindDuplicates("")
+	if !reflect.DeepEqual(groups, want) {
+		t.Errorf("FindDuplicates() = %+v, want %+v", groups, want)
+	}
+}
+
+func TestService_CreateCar_GeneratesID(t *testing.T) {
+	uuidPattern := regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)
+
+	service := NewService(NewInMemoryRepository(), WithIDGenerator(UUIDGenerator{}))
+	created, err := service.CreateCar(Car{Make: "Ford", Model: "Focus", Year: 2020})
+	if err != nil {
+		t.Fatalf("CreateCar() error = %v", err)
+	}
+	if !uuidPattern.MatchString(created.ID) {
+		t.Errorf("CreateCar() assigned ID %q, want a UUID", created.ID)
+	}
+
+	// Client-supplied IDs are kept as-is
+	created, err = service.CreateCar(Car{ID: "client-id", Make: "Ford", Model: "Focus", Year: 2020})
+	if err != nil {
+		t.Fatalf("CreateCar() error = %v", err)
+	}
+	if created.ID != "client-id" {
+		t.Errorf("CreateCar() ID = %q, want client-id", created.ID)
+	}
+
+	service = NewService(NewInMemoryRepository(), WithIDGenerator(NewSequenceGenerator("CAR-")))
+	first, _ := service.CreateCar(Car{Make: "Ford", Model: "Focus", Year: 2020})
+	second, _ := service.CreateCar(Car{Make: "Ford", Model: "Focus", Year: 2020})
+	if first.ID != "CAR-0001" || second.ID != "CAR-0002" {
+		t.Errorf("CreateCar() sequence IDs = %q, %q, want CAR-0001, CAR-0002", first.ID, second.ID)
+	}
+
+	// Each tenant has its own sequence
+	other, err := service.CreateCar(Car{TenantID: "other", Make: "Ford", Model: "Focus", Year: 2020})
+	if err != nil || other.ID != "CAR-0001" {
+		t.Errorf("CreateCar() first ID for a new tenant = %q, %v, want CAR-0001", other.ID, err)
+	}
+
+	// IDs a client already took are skipped
+	service.CreateCar(Car{ID: "CAR-0003", Make: "Ford", Model: "Focus", Year: 2020})
+	service.CreateCar(Car{ID: "CAR-0004", Make: "Ford", Model: "Focus", Year: 2020})
+	next, err := service.CreateCar(Car{Make: "Ford", Model: "Focus", Year: 2020})
+	if err != nil || next.ID != "CAR-0005" {
+		t.Errorf("CreateCar() after client-supplied CAR-0003 and CAR-0004 = %q, %v, want CAR-0005", next.ID, err)
+	}
+}
+
+func TestService_CreateCar_TenantIDGenerator(t *testing.T) {
+	service := NewService(NewInMemoryRepository(), WithTenantIDGenerator("seq", NewSequenceGenerator("CAR-")))
+
+	created, err := service.CreateCar(Car{TenantID: "seq", Make: "Ford", Model: "Focus", Year: 2020})
+	if err != nil || created.ID != "CAR-0001" {
+		t.Errorf("CreateCar() for opted-in tenant = %q, %v, want CAR-0001", created.ID, err)
+	}
+
+	// Tenants that didn't opt in still have to supply their own IDs
+	if _, err := service.CreateCar(Car{TenantID: "plain", Make: "Ford", Model: "Focus", Year: 2020}); err == nil {
+		t.Error("CreateCar() without ID for a tenant without a generator expected error")
+	}
+}
+
+func TestSequenceGenerator_Concurrent(t *testing.T) {
+	service := NewService(NewInMemoryRepository(), WithIDGenerator(NewSequenceGenerator("CAR-")))
+
+	const workers = 50
+	const perWorker = 20
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	seen := make(map[string]bool)
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < perWorker; i++ {
+				created, err := service.CreateCar(Car{TenantID: "t", Make: "Ford", Model: "Focus", Year: 2020})
+				if err != nil {
+					t.Errorf("CreateCar() error = %v", err)
+					return
+				}
+				mu.Lock()
+				if seen[created.ID] {
+					t.Errorf("duplicate ID %q", created.ID)
+				}
+				seen[created.ID] = true
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	if len(seen) != workers*perWorker {
+		t.Errorf("got %d unique IDs, want %d", len(seen), workers*perWorker)
+	}
+	if got := len(service.GetAllCars("t")); got != workers*perWorker {
+		t.Errorf("stored %d cars, want %d", got, workers*perWorker)
+	}
+}
+
+func TestNewIDGenerator(t *testing.T) {
+	if gen, err := NewIDGenerator("client", ""); err != nil || gen != nil {
+		t.Errorf("NewIDGenerator(client) = %v, %v, want nil, nil", gen, err)
+	}
+	if _, err := NewIDGenerator("random", ""); err == nil {
+		t.Error("NewIDGenerator(random) expected error for unknown strategy")
+	}
+}
+
+func TestValidIDPrefix(t *testing.T) {
+	for prefix, want := range map[string]bool{
+		"CAR-":   true,
+		"fleet_": true,
+		"":       true,
+		"CAR/":   false,
+		"car ":   false,
+		"CAR#":   false,
+	} {
+		if got := ValidIDPrefix(prefix); got != want {
+			t.Errorf("ValidIDPrefix(%q) = %v, want %v", prefix, got, want)
+		}
+	}
+}
+
+func TestService_GetFilteredCars_NearYear(t *testing.T) {
+	repo := NewInMemoryRepository()
+	service := NewService(repo)
+
+	repo.Create(Car{ID: "near-1", Make: "Honda", Model: "Civic", Year: 2010})
+	repo.Create(Car{ID: "near-2", Make: "Honda", Model: "Civic", Year: 2018})
+	repo.Create(Car{ID: "near-3", Make: "Honda", Model: "Civic", Year: 2021})
+	repo.Create(Car{ID: "near-4", Make: "Toyota", Model: "Corolla", Year: 2019})
+
+	cars := service.GetFilteredCars("", FilterOptions{Make: "Honda", NearYear: 2019}, []SortOptions{{Field: "id"}})
+	if len(cars) != 3 {
+		t.Fatalf("GetFilteredCars() returned %d cars, want 3", len(cars))
+	}
+
+	want := []string{"near-2", "near-3", "near-1"}
+	for i, id := range want {
+		if cars[i].ID != id {
+			t.Errorf("GetFilteredCars() order = %v, want %v", carIDs(cars), want)
+			break
+		}
+	}
+}
+
+// carIDs returns the IDs of the given cars in order
+func carIDs(cars []Car) []string {
+	ids := make([]string, len(cars))
+	for i, c := range cars {
+		ids[i] = c.ID
+	}
+	return ids
+}
+
+func TestService_CreateCars(t *testing.T) {
+	repo := NewInMemoryRepository()
+	service := NewService(repo)
+	repo.Create(Car{ID: "batch-existing", Make: "Ford", Model: "Focus", Year: 2018})
+
+	result := service.CreateCars("", []Car{
+		{ID: "batch-1", Make: "Honda", Model: "Civic", Year: 2020},
+		{ID: "batch-2", Make: "", Model: "Civic", Year: 2020},
+		{ID: "batch-existing", Make: "Ford", Model: "Focus", Year: 2018},
+		{ID: "batch-3", Make: "Kia", Model: "Rio", Year: 2021},
+	})
+
+	if result.Summary != (BatchSummary{Total: 4, Succeeded: 2, Failed: 2}) {
+		t.Errorf("CreateCars() summary = %+v, want 2 succeeded and 2 failed of 4", result.Summary)
+	}
+
+	if ids := carIDs(result.Succeeded); !slices.Equal(ids, []string{"batch-1", "batch-3"}) {
+		t.Errorf("CreateCars() succeeded = %v, want batch-1 and batch-3", ids)
+	}
+	if len(result.Failed) != 2 {
+		t.Fatalf("CreateCars() failed = %+v, want 2 failures", result.Failed)
+	}
+	if f := result.Failed[0]; f.Index != 1 || f.Input.Model != "Civic" || f.Code != apierror.ValidationFailed || f.Field != "make" || f.Error == "" {
+		t.Errorf("CreateCars() failed[0] = %+v, want a make validation failure at index 1", f)
+	}
+	if f := result.Failed[1]; f.Index != 2 || f.Code != apierror.Conflict || f.Error != ErrConflict.Error() {
+		t.Errorf("CreateCars() failed[1] = %+v, want a conflict at index 2", f)
+	}
+
+	if _, err := repo.Get("batch-3", ""); err != nil {
+		t.Errorf("Expected batch-3 to be stored despite earlier failures, got %v", err)
+	}
+}
+
+func TestService_PatchCar(t *testing.T) {
+	repo := NewInMemoryRepository()
+	service := NewService(repo)
+	repo.Create(Car{ID: "patch-1", Make: "Mazda", Model: "3", Year: 2019, Color: "red"})
+
+	color := "grey"
+	patched, err := service.PatchCar("patch-1", "", CarPatch{Color: &color})
+	if err != nil {
+		t.Fatalf("PatchCar() error = %v", err)
+	}
+	if patched.Color != "grey" || patched.Make != "Mazda" || patched.Year != 2019 {
+		t.Errorf("PatchCar() = %+v, want only color changed", patched)
+	}
+
+	// Validation runs on the merged result
+	empty := ""
+	if _, err := service.PatchCar("patch-1", "", CarPatch{Make: &empty}); err == nil {
+		t.Error("PatchCar() expected error when clearing make")
+	}
+
+	if _, err := service.PatchCar("nonexistent", "", CarPatch{Color: &color}); err != ErrNotFound {
+		t.Errorf("PatchCar() error = %v, want %v", err, ErrNotFound)
+	}
+}
+
+func TestService_TenantScoping(t *testing.T) {
+	service := NewService(NewInMemoryRepository())
+	if _, err := service.CreateCar(Car{ID: "scoped", TenantID: "a", Make: "Kia", Model: "Rio", Year: 2018}); err != nil {
+		t.Fatalf("CreateCar: %v", err)
+	}
+
+	if _, err := service.GetCar("scoped", "b"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("GetCar from other tenant error = %v, want ErrNotFound", err)
+	}
+	if got := len(service.GetAllCars("b")); got != 0 {
+		t.Errorf("GetAllCars(b) returned %d cars, want 0", got)
+	}
+	if got := len(service.GetAllCars("a")); got != 1 {
+		t.Errorf("GetAllCars(a) returned %d cars, want 1", got)
+	}
+	if _, err := service.UpdateCar(Car{ID: "scoped", TenantID: "b", Make: "Kia", Model: "Rio", Year: 2019}); !errors.Is(err, ErrNotFound) {
+		t.Errorf("UpdateCar from other tenant error = %v, want ErrNotFound", err)
+	}
+	if err := service.DeleteCar("scoped", "b"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("DeleteCar from other tenant error = %v, want ErrNotFound", err)
+	}
+	if err := service.DeleteCar("scoped", "a"); err != nil {
+		t.Errorf("DeleteCar from owner: %v", err)
+	}
+}
+
+func TestService_CompareCars(t *testing.T) {
+	repo := NewInMemoryRepository()
+	service := NewService(repo)
+
+	repo.Create(Car{ID: "cmp-1", Make: "Honda", Model: "Civic", Year: 2019, Color: "red"})
+	repo.Create(Car{ID: "cmp-2", Make: "Honda", Model: "Accord", Year: 2021, Color: "red"})
+	repo.Create(Car{ID: "cmp-other", TenantID: "other", Make: "Honda", Model: "Civic", Year: 2019})
+
+	comparison, err := service.CompareCars("", "cmp-1", "cmp-2")
+	if err != nil {
+		t.Fatalf("CompareCars() error = %v", err)
+	}
+	if comparison.Cars[0].ID != "cmp-1" || comparison.Cars[1].ID != "cmp-2" {
+		t.Errorf("CompareCars() cars = %v, want cmp-1 then cmp-2", comparison.Cars)
+	}
+	if len(comparison.Differences) != 2 {
+		t.Errorf("CompareCars() differences = %v, want model and year", comparison.Differences)
+	}
+	if got := comparison.Differences["year"]; got != [2]interface{}{2019, 2021} {
+		t.Errorf("year difference = %v, want [2019 2021]", got)
+	}
+	if _, ok := comparison.Differences["make"]; ok {
+		t.Error("make should not be reported as a difference")
+	}
+
+	if _, err := service.CompareCars("", "cmp-1", "cmp-other"); err != ErrNotFound {
+		t.Errorf("CompareCars() with another tenant's car error = %v, want ErrNotFound", err)
+	}
+}
+
+func TestService_SoftDelete(t *testing.T) {
+	repo := NewInMemoryRepository()
+	service := NewService(repo)
+
+	repo.Create(Car{ID: "soft-1", Make: "Volvo", Model: "XC60", Year: 2020})
+	repo.Create(Car{ID: "soft-2", Make: "Volvo", Model: "XC90", Year: 2021})
+
+	if err := service.DeleteCar("soft-1", ""); err != nil {
+		t.Fatalf("DeleteCar() error = %v", err)
+	}
+
+	// The row is kept, but hidden from normal reads and writes
+	stored, err := repo.Get("soft-1", "")
+	if err != nil || !stored.IsDeleted() {
+		t.Errorf("stored car = %v, %v, want it kept with DeletedAt set", stored, err)
+	}
+	if _, err := service.GetCar("soft-1", ""); err != ErrNotFound {
+		t.Errorf("GetCar() on deleted car error = %v, want ErrNotFound", err)
+	}
+	if got := len(service.GetAllCars("")); got != 1 {
+		t.Errorf("GetAllCars() returned %d cars, want 1", got)
+	}
+	if got := service.GetPagedCars("", FilterOptions{}, nil, PaginationOptions{Page: 1, PageSize: 10}).TotalItems; got != 1 {
+		t.Errorf("GetPagedCars() total = %d, want 1", got)
+	}
+	if got := len(service.GetFilteredCars("", FilterOptions{IncludeDeleted: true}, nil)); got != 2 {
+		t.Errorf("GetFilteredCars(IncludeDeleted) returned %d cars, want 2", got)
+	}
+	if _, err := service.UpdateCar(Car{ID: "soft-1", Make: "Volvo", Model: "XC60", Year: 2022}); err != ErrNotFound {
+		t.Errorf("UpdateCar() on deleted car error = %v, want ErrNotFound", err)
+	}
+	if err := service.DeleteCar("soft-1", ""); err != ErrNotFound {
+		t.Errorf("DeleteCar() twice error = %v, want ErrNotFound", err)
+	}
+
+	restored, err := service.RestoreCar("soft-1", "")
+	if err != nil || restored.IsDeleted() {
+		t.Errorf("RestoreCar() = %v, %v, want an active car", restored, err)
+	}
+	if _, err := service.GetCar("soft-1", ""); err != nil {
+		t.Errorf("GetCar() after restore error = %v", err)
+	}
+	if _, err := service.RestoreCar("missing", ""); err != ErrNotFound {
+		t.Errorf("RestoreCar() on missing car error = %v, want ErrNotFound", err)
+	}
+}
+
+func TestService_CountCars(t *testing.T) {
+	repo := NewInMemoryRepository()
+	service := NewService(repo)
+
+	repo.Create(Car{ID: "count-1", Make: "Toyota", Model: "Corolla", Year: 2020, Color: "blue"})
+	repo.Create(Car{ID: "count-2", Make: "Toyota", Model: "Camry", Year: 2021, Color: "red"})
+	repo.Create(Car{ID: "count-3", Make: "Honda", Model: "Civic", Year: 2020, Color: "blue"})
+	repo.Create(Car{ID: "count-4", Make: "Toyota", Model: "Yaris", Year: 2020, Color: "blue"})
+	service.DeleteCar("count-4", "")
+
+	tests := []struct {
+		name   string
+		filter FilterOptions
+		want   int
+	}{
+		{"no filter", FilterOptions{}, 3},
+		{"by make", FilterOptions{Make: "toyota"}, 2},
+		{"by year and color", FilterOptions{Year: 2020, Color: "blue"}, 2},
+		{"no match", FilterOptions{Make: "Ford"}, 0},
+		{"including deleted", FilterOptions{Make: "Toyota", IncludeDeleted: true}, 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := service.CountCars("", tt.filter); got != tt.want {
+				t.Errorf("CountCars() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestApplySorting_MultipleFields(t *testing.T) {
+	cars := []Car{
+		{ID: "1", Make: "Toyota", Year: 2019},
+		{ID: "2", Make: "honda", Year: 2018},
+		{ID: "3", Make: "Toyota", Year: 2021},
+		{ID: "4", Make: "Honda", Year: 2022},
+		{ID: "5", Make: "Toyota", Year: 2021},
+	}
+
+	sorted := applySorting(cars, []SortOptions{{Field: "make", Order: "asc"}, {Field: "year", Order: "desc"}})
+
+	// Ties on both keys (3 and 5) keep their input order
+	want := []string{"4", "2", "3", "5", "1"}
+	for i, car := range sorted {
+		if car.ID != want[i] {
+			t.Fatalf("applySorting() order = %v, want %v", carIDs(sorted), want)
+		}
+	}
+}
+
+func TestApplySorting_CreatedAt(t *testing.T) {
+	base := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	cars := []Car{
+		{ID: "1", CreatedAt: timestamp.New(base.Add(time.Hour))},
+		{ID: "2", CreatedAt: timestamp.New(base)},
+		{ID: "3", CreatedAt: timestamp.New(base.Add(2 * time.Hour))},
+	}
+
+	sorted := applySorting(cars, []SortOptions{{Field: "created_at", Order: "desc"}})
+
+	if got, want := carIDs(sorted), []string{"3", "1", "2"}; !slices.Equal(got, want) {
+		t.Errorf("applySorting() order = %v, want %v", got, want)
+	}
+}
+
+func TestApplyFilters(t *testing.T) {
+	cars := []Car{
+		{ID: "1", Make: "Toyota", Model: "Corolla", Year: 2020, Color: "blue"},
+		{ID: "2", Make: "Toyota", Model: "Camry", Year: 2021, Color: "red"},
+		{ID: "3", Make: "Tesla", Model: "Model 3", Year: 2022, Color: "white"},
+		{ID: "4", Make: "Honda", Model: "Civic", Year: 2020, Color: "blue"},
+	}
+
+	tests := []struct {
+		name   string
+		filter FilterOptions
+		want   []string
+	}{
+		{"no filter", FilterOptions{}, []string{"1", "2", "3", "4"}},
+		{"exact make ignores case", FilterOptions{Make: "toyota"}, []string{"1", "2"}},
+		{"exact make needs the full value", FilterOptions{Make: "Toy"}, nil},
+		{"make prefix", FilterOptions{MakePrefix: "t"}, []string{"1", "2", "3"}},
+		{"make prefix is anchored", FilterOptions{MakePrefix: "ota"}, nil},
+		{"model prefix ignores case", FilterOptions{ModelPrefix: "CA"}, []string{"2"}},
+		{"prefix combined with other filters", FilterOptions{MakePrefix: "to", Year: 2020}, []string{"1"}},
+		{"contains make", FilterOptions{Make: "OYO", Match: MatchContains}, []string{"1", "2"}},
+		{"contains model and color", FilterOptions{Model: "c", Color: "LU", Match: MatchContains}, []string{"1", "4"}},
+		{"contains leaves year exact", FilterOptions{Make: "t", Year: 202, Match: MatchContains}, nil},
+		{"contains ignores empty values", FilterOptions{Match: MatchContains, Year: 2020}, []string{"1", "4"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := carIDs(applyFilters(cars, tt.filter))
+			if !slices.Equal(got, tt.want) && !(len(got) == 0 && len(tt.want) == 0) {
+				t.Errorf("applyFilters() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateVIN(t *testing.T) {
+	tests := []struct {
+		vin     string
+		wantErr string
+	}{
+		{"1M8GDM9AXKP042788", ""},
+		{"1HGCM82633A004352", ""},
+		{"11111111111111111", ""},
+		{"1HGCM82643A004352", "check digit"},
+		{"1HGCM8263", "17 characters"},
+		{"1HGCM82633A00435IO", "17 characters"},
+		{"1HGCM82633A0O4352", "other than I, O and Q"},
+		{"1HGCM82633A00435-", "other than I, O and Q"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.vin, func(t *testing.T) {
+			err := validateVIN(tt.vin)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("validateVIN() error = %v, want nil", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("validateVIN() error = %v, want it to contain %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestService_VINUniquePerTenant(t *testing.T) {
+	service := NewService(NewInMemoryRepository())
+	const vin = "1HGCM82633A004352"
+
+	created, err := service.CreateCar(Car{ID: "vin-1", TenantID: "a", Make: "Honda", Model: "Accord", Year: 2003, VIN: strings.ToLower(vin)})
+	if err != nil {
+		t.Fatalf("CreateCar() error = %v", err)
+	}
+	if created.VIN != vin {
+		t.Errorf("CreateCar() VIN = %q, want it upper-cased to %q", created.VIN, vin)
+	}
+
+	// Cars without a VIN never conflict
+	service.CreateCar(Car{ID: "no-vin-1", TenantID: "a", Make: "Honda", Model: "Civic", Year: 2010})
+	if _, err := service.CreateCar(Car{ID: "no-vin-2", TenantID: "a", Make: "Honda", Model: "Civic", Year: 2010}); err != nil {
+		t.Errorf("CreateCar() without VIN error = %v", err)
+	}
+
+	_, err = service.CreateCar(Car{ID: "vin-2", TenantID: "a", Make: "Honda", Model: "Accord", Year: 2003, VIN: vin})
+	var conflictErr *ConflictError
+	if !errors.Is(err, ErrDuplicateVIN) || !errors.As(err, &conflictErr) || conflictErr.Field != "vin" {
+		t.Errorf("CreateCar() with duplicate VIN error = %v, want a ConflictError on vin wrapping ErrDuplicateVIN", err)
+	}
+	if _, err := service.CreateCar(Car{ID: "vin-1", TenantID: "b", Make: "Honda", Model: "Accord", Year: 2003, VIN: vin}); err != nil {
+		t.Errorf("CreateCar() with same VIN in another tenant error = %v", err)
+	}
+
+	vinPtr := vin
+	if _, err := service.PatchCar("no-vin-1", "a", CarPatch{VIN: &vinPtr}); !errors.Is(err, ErrDuplicateVIN) {
+		t.Errorf("PatchCar() to a taken VIN error = %v, want ErrDuplicateVIN", err)
+	}
+
+	found, err := service.GetCarByVIN(strings.ToLower(vin), "a")
+	if err != nil || found.ID != "vin-1" {
+		t.Errorf("GetCarByVIN() = %v, %v, want vin-1", found, err)
+	}
+	if _, err := service.GetCarByVIN("11111111111111111", "a"); err != ErrNotFound {
+		t.Errorf("GetCarByVIN() for unknown VIN error = %v, want ErrNotFound", err)
+	}
+}
+
+func TestService_ImportCars(t *testing.T) {
+	const vin = "1M8GDM9AXKP042788"
+
+	service := NewService(NewInMemoryRepository())
+	service.CreateCar(Car{ID: "existing", TenantID: "t1", Make: "Ford", Model: "Focus", Year: 2018})
+
+	yearErr := &ValidationError{Field: "year", Message: "year must be a number"}
+	cars := []Car{
+		{ID: "c1", Make: "Toyota", Model: "Corolla", Year: 2020, VIN: vin},
+		{ID: "existing", Make: "Honda", Model: "Civic", Year: 2019},
+		{ID: "c2", Make: "", Model: "Civic", Year: 2019},
+		{ID: "c1", Make: "Mazda", Model: "3", Year: 2021},
+		{ID: "c3", Make: "Mazda", Model: "6", Year: 2021, VIN: strings.ToLower(vin)},
+		{ID: "c4", Make: "Kia", Model: "Rio", Year: 2022},
+		{ID: "c5", Make: "Kia", Model: "Rio"},
+	}
+	rows := make([]ImportRow, len(cars))
+	for i, car := range cars {
+		rows[i] = ImportRow{Car: car}
+	}
+	rows[6].Err = yearErr
+
+	wantFailed := []BatchFailure[Car]{
+		{Index: 1, Input: cars[1], Code: apierror.Conflict, Error: ErrConflict.Error(), Field: "id"},
+		{Index: 2, Input: cars[2], Code: apierror.ValidationFailed, Error: "make is required", Field: "make"},
+		{Index: 3, Input: cars[3], Code: apierror.Conflict, Error: ErrConflict.Error(), Field: "id"},
+		{Index: 4, Input: cars[4], Code: apierror.Conflict, Error: ErrDuplicateVIN.Error(), Field: "vin"},
+		{Index: 6, Input: cars[6], Code: apierror.ValidationFailed, Error: yearErr.Error(), Field: "year"},
+	}
+
+	for _, dryRun := range []bool{true, false} {
+		result := service.ImportCars("t1", rows, dryRun)
+
+		if result.DryRun != dryRun || result.Summary != (BatchSummary{Total: 7, Succeeded: 2, Failed: 5}) {
+			t.Errorf("ImportCars(dryRun=%v) = %+v, want 2 succeeded and 5 failed", dryRun, result)
+		}
+		if ids := carIDs(result.Succeeded); !slices.Equal(ids, []string{"c1", "c4"}) {
+			t.Errorf("ImportCars(dryRun=%v) succeeded = %v, want c1 and c4", dryRun, ids)
+		}
+		if !slices.EqualFunc(result.Failed, wantFailed, func(a, b BatchFailure[Car]) bool {
+			return a.Index == b.Index && a.Input.ID == b.Input.ID && a.Code == b.Code && a.Error == b.Error && a.Field == b.Field
+		}) {
+			t.Errorf("ImportCars(dryRun=%v) failed = %+v, want %+v", dryRun, result.Failed, wantFailed)
+		}
+
+		wantStored := 3
+		if dryRun {
+			wantStored = 1
+		}
+		if got := len(service.GetAllCars("t1")); got != wantStored {
+			t.Errorf("after ImportCars(dryRun=%v) stored cars = %d, want %d", dryRun, got, wantStored)
+		}
+	}
+}
+
+func TestService_Cache(t *testing.T) {
+	repo := NewInMemoryRepository()
+	service := NewService(repo, WithCache(cache.New(0, 0), time.Minute))
+
+	service.CreateCar(Car{ID: "c1", TenantID: "t1", Make: "Toyota", Model: "Corolla", Year: 2020})
+	if _, err := service.GetCar("c1", "t1"); err != nil {
+		t.Fatalf("GetCar() error = %v", err)
+	}
+
+	// A change behind the service's back is hidden by the cached copy
+	repo.Update(Car{ID: "c1", TenantID: "t1", Make: "Toyota", Model: "Camry", Year: 2020})
+	if car, _ := service.GetCar("c1", "t1"); car.Model != "Corolla" {
+		t.Errorf("GetCar() model = %q, want cached %q", car.Model, "Corolla")
+	}
+
+	// Changes made through the service invalidate the cached copy
+	service.UpdateCar(Car{ID: "c1", TenantID: "t1", Make: "Toyota", Model: "Prius", Year: 2021})
+	if car, _ := service.GetCar("c1", "t1"); car.Model != "Prius" {
+		t.Errorf("GetCar() after update model = %q, want %q", car.Model, "Prius")
+	}
+
+	service.DeleteCar("c1", "t1")
+	if _, err := service.GetCar("c1", "t1"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("GetCar() after delete error = %v, want ErrNotFound", err)
+	}
+
+	service.RestoreCar("c1", "t1")
+	if _, err := service.GetCar("c1", "t1"); err != nil {
+		t.Errorf("GetCar() after restore error = %v", err)
+	}
+
+	// Cached cars stay scoped to their tenant
+	if _, err := service.GetCar("c1", "t2"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("GetCar() for another tenant error = %v, want ErrNotFound", err)
+	}
+}
+
+// countingRepository counts the Get calls that reach the wrapped repository
+type countingRepository struct {
+	Repository
+	gets int
+}
+
+func (r *countingRepository) Get(id, tenantID string) (Car, error) {
+	r.gets++
+	return r.Repository.Get(id, tenantID)
+}
+
+// pausingRepository pauses the first Get after it has read the car, until
+// release is closed
+type pausingRepository struct {
+	Repository
+	paused  atomic.Bool
+	loaded  chan struct{}
+	release chan struct{}
+}
+
+func (r *pausingRepository) Get(id, tenantID string) (Car, error) {
+	car, err := r.Repository.Get(id, tenantID)
+	if r.paused.CompareAndSwap(false, true) {
+		close(r.loaded)
+		<-r.release
+	}
+	return car, err
+}
+
+func TestService_CacheFillRacingWrite(t *testing.T) {
+	tests := []struct {
+		name  string
+		write func(s *Service) error
+		check func(car Car, err error) bool
+	}{
+		{
+			name: "update",
+			write: func(s *Service) error {
+				_, err := s.UpdateCar(Car{ID: "c1", TenantID: "t1", Make: "Toyota", Model: "Prius", Year: 2021})
+				return err
+			},
+			check: func(car Car, err error) bool { return err == nil && car.Model == "Prius" },
+		},
+		{
+			name:  "delete",
+			write: func(s *Service) error { return s.DeleteCar("c1", "t1") },
+			check: func(car Car, err error) bool { return errors.Is(err, ErrNotFound) },
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			inner := NewInMemoryRepository()
+			inner.Create(Car{ID: "c1", TenantID: "t1", Make: "Toyota", Model: "Corolla", Year: 2020})
+			repo := &pausingRepository{Repository: inner, loaded: make(chan struct{}), release: make(chan struct{})}
+			service := NewService(repo, WithCache(cache.New(0, 0), time.Minute))
+
+			// A reader loads the old car and stalls before caching it
+			var wg sync.WaitGroup
+			wg.Add(2)
+			go func() {
+				defer wg.Done()
+				service.GetCar("c1", "t1")
+			}()
+			<-repo.loaded
+
+			// A writer changes the car meanwhile; it must not finish before
+			// the reader's fill, or the fill must not outlive its write
+			go func() {
+				defer wg.Done()
+				if err := tt.write(service); err != nil {
+					t.Errorf("write error = %v", err)
+				}
+			}()
+			time.Sleep(20 * time.Millisecond)
+			close(repo.release)
+			wg.Wait()
+
+			if car, err := service.GetCar("c1", "t1"); !tt.check(car, err) {
+				t.Errorf("GetCar() after racing %s = %+v, %v, want the written state", tt.name, car, err)
+			}
+		})
+	}
+}
+
+func TestService_CacheWriteThrough(t *testing.T) {
+	tests := []struct {
+		name     string
+		opts     []Option
+		wantGets int
+	}{
+		{name: "invalidate", wantGets: 1},
+		{name: "write-through", opts: []Option{WithWriteThrough()}, wantGets: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &countingRepository{Repository: NewInMemoryRepository()}
+			opts := append([]Option{WithCache(cache.New(0, 0), time.Minute)}, tt.opts...)
+			service := NewService(repo, opts...)
+
+			service.CreateCar(Car{ID: "c1", TenantID: "t1", Make: "Toyota", Model: "Corolla", Year: 2020})
+			repo.gets = 0
+			if car, err := service.GetCar("c1", "t1"); err != nil || car.Model != "Corolla" {
+				t.Errorf("GetCar() after create = %+v, %v, want the created car", car, err)
+			}
+			if repo.gets != tt.wantGets {
+				t.Errorf("repository reads after create = %d, want %d", repo.gets, tt.wantGets)
+			}
+
+			service.UpdateCar(Car{ID: "c1", TenantID: "t1", Make: "Toyota", Model: "Prius", Year: 2021})
+			repo.gets = 0
+			if car, err := service.GetCar("c1", "t1"); err != nil || car.Model != "Prius" {
+				t.Errorf("GetCar() after update = %+v, %v, want the updated car", car, err)
+			}
+			if repo.gets != tt.wantGets {
+				t.Errorf("repository reads after update = %d, want %d", repo.gets, tt.wantGets)
+			}
+
+			service.DeleteCar("c1", "t1")
+			if _, err := service.GetCar("c1", "t1"); !errors.Is(err, ErrNotFound) {
+				t.Errorf("GetCar() after delete error = %v, want ErrNotFound", err)
+			}
+		})
+	}
+}
+
+func TestService_CacheWriteThroughConcurrentUpdates(t *testing.T) {
+	repo := NewInMemoryRepository()
+	service := NewService(repo, WithCache(cache.New(0, 0), time.Minute), WithWriteThrough())
+	service.CreateCar(Car{ID: "c1", TenantID: "t1", Make: "Toyota", Model: "Corolla", Year: 2020})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			service.UpdateCar(Car{ID: "c1", TenantID: "t1", Make: "Toyota", Model: "Model " + strconv.Itoa(i), Year: 2020})
+		}(i)
+	}
+	wg.Wait()
+
+	stored, _ := repo.Get("c1", "t1")
+	if cached, _ := service.GetCar("c1", "t1"); cached.Model != stored.Model {
+		t.Errorf("cached model = %q, want the last stored %q", cached.Model, stored.Model)
+	}
+}
+
+func TestService_BulkCreatesDontWriteThrough(t *testing.T) {
+	repo := &countingRepository{Repository: NewInMemoryRepository()}
+	service := NewService(repo, WithCache(cache.New(0, 0), time.Minute), WithWriteThrough())
+
+	service.CreateCars("t1", []Car{{ID: "b1", Make: "Kia", Model: "Rio", Year: 2020}})
+	service.ImportCars("t1", []ImportRow{{Car: Car{ID: "i1", Make: "Kia", Model: "Rio", Year: 2020}}}, false)
+
+	repo.gets = 0
+	service.GetCar("b1", "t1")
+	service.GetCar("i1", "t1")
+	if repo.gets != 2 {
+		t.Errorf("repository reads after bulk creates = %d, want 2", repo.gets)
+	}
+}
+
+func TestService_GetTimeline(t *testing.T) {
+	at := func(date string) timestamp.Time {
+		d, _ := time.Parse(time.DateOnly, date)
+		return timestamp.New(d.Add(15 * time.Hour))
+	}
+	deleted := at("2024-01-02")
+
+	repo := NewInMemoryRepository()
+	for _, car := range []Car{
+		{ID: "c1", TenantID: "t1", CreatedAt: at("2024-01-01")}, // Monday
+		{ID: "c2", TenantID: "t1", CreatedAt: at("2024-01-03")},
+		{ID: "c3", TenantID: "t1", CreatedAt: at("2024-01-10")},
+		{ID: "c4", TenantID: "t1", CreatedAt: at("2024-02-05")},
+		{ID: "c5", TenantID: "t1", CreatedAt: at("2024-01-02"), DeletedAt: &deleted},
+		{ID: "o1", TenantID: "t2", CreatedAt: at("2024-01-01")},
+	} {
+		repo.Create(car)
+	}
+
+	tests := []struct {
+		interval   string
+		from, to   string
+		wantStarts []string
+		wantCounts []int
+	}{
+		{IntervalDay, "2024-01-01", "2024-01-03", []string{"2024-01-01", "2024-01-02", "2024-01-03"}, []int{1, 0, 1}},
+		{IntervalWeek, "2024-01-03", "2024-01-14", []string{"2024-01-01", "2024-01-08"}, []int{2, 1}},
+		{IntervalMonth, "2024-01-15", "2024-02-01", []string{"2024-01-01", "2024-02-01"}, []int{3, 1}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.interval, func(t *testing.T) {
+			from, _ := time.Parse(time.DateOnly, tt.from)
+			to, _ := time.Parse(time.DateOnly, tt.to)
+
+			timeline, err := NewService(repo).GetTimeline("t1", tt.interval, from, to)
+			if err != nil {
+				t.Fatalf("GetTimeline() error = %v", err)
+			}
+
+			var starts []string
+			var counts []int
+			for _, b := range timeline.Buckets {
+				starts = append(starts, b.Start.Format(time.DateOnly))
+				counts = append(counts, b.Count)
+			}
+			if !slices.Equal(starts, tt.wantStarts) || !slices.Equal(counts, tt.wantCounts) {
+				t.Errorf("GetTimeline() buckets = %v %v, want %v %v", starts, counts, tt.wantStarts, tt.wantCounts)
+			}
+		})
+	}
+
+	if _, err := NewService(repo).GetTimeline("t1", "year", time.Now(), time.Now()); !errors.Is(err, ErrInvalidInterval) {
+		t.Errorf("GetTimeline() with invalid interval error = %v, want ErrInvalidInterval", err)
+	}
+}
+
+func TestService_GetTimelineIsCached(t *testing.T) {
+	service := NewService(NewInMemoryRepository(), WithCache(cache.New(0, 0), time.Minute))
+	now := time.Now().UTC().Truncate(24 * time.Hour)
+
+	service.CreateCar(Car{ID: "c1", TenantID: "t1", Make: "Kia", Model: "Rio", Year: 2020})
+	first, _ := service.GetTimeline("t1", IntervalDay, now, now)
+
+	// A later time within the same bucket reuses the cached timeline
+	service.CreateCar(Car{ID: "c2", TenantID: "t1", Make: "Kia", Model: "Rio", Year: 2020})
+	second, _ := service.GetTimeline("t1", IntervalDay, now, now.Add(time.Hour))
+
+	if first.Buckets[0].Count != 1 || second.Buckets[0].Count != 1 {
+		t.Errorf("GetTimeline() counts = %d, %d, want the cached 1 both times", first.Buckets[0].Count, second.Buckets[0].Count)
+	}
+}
+
+func TestService_GetGroupedCars(t *testing.T) {
+	service := NewService(NewInMemoryRepository())
+	for _, car := range []Car{
+		{ID: "t1", Make: "Toyota", Model: "Corolla", Year: 2020},
+		{ID: "h1", Make: "Honda", Model: "Civic", Year: 2021},
+		{ID: "t2", Make: "toyota", Model: "Camry", Year: 2022},
+		{ID: "h2", Make: "Honda", Model: "Accord", Year: 2020},
+		{ID: "k1", Make: "Kia", Model: "Rio", Year: 2019},
+	} {
+		if _, err := service.CreateCar(car); err != nil {
+			t.Fatalf("CreateCar() error = %v", err)
+		}
+	}
+
+	tests := []struct {
+		name     string
+		field    string
+		filter   FilterOptions
+		sort     []SortOptions
+		wantKeys []string
+		wantIDs  [][]string
+	}{
+		{
+			name:     "make sorted by year",
+			field:    "make",
+			sort:     []SortOptions{{Field: "year", Order: "asc"}},
+			wantKeys: []string{"Honda", "Kia", "Toyota"},
+			wantIDs:  [][]string{{"h2", "h1"}, {"k1"}, {"t1", "t2"}},
+		},
+		{
+			name:     "make keyed by first car",
+			field:    "make",
+			sort:     []SortOptions{{Field: "year", Order: "desc"}},
+			wantKeys: []string{"Honda", "Kia", "toyota"},
+			wantIDs:  [][]string{{"h1", "h2"}, {"k1"}, {"t2", "t1"}},
+		},
+		{
+			name:     "year descending",
+			field:    "year",
+			sort:     []SortOptions{{Field: "year", Order: "desc"}, {Field: "id", Order: "asc"}},
+			wantKeys: []string{"2022", "2021", "2020", "2019"},
+			wantIDs:  [][]string{{"t2"}, {"h1"}, {"h2", "t1"}, {"k1"}},
+		},
+		{
+			name:     "filtered",
+			field:    "model",
+			filter:   FilterOptions{Make: "honda"},
+			sort:     []SortOptions{{Field: "id", Order: "asc"}},
+			wantKeys: []string{"Accord", "Civic"},
+			wantIDs:  [][]string{{"h2"}, {"h1"}},
+		},
+		{
+			name:     "no matches",
+			field:    "make",
+			filter:   FilterOptions{Make: "Tesla"},
+			wantKeys: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := service.GetGroupedCars("", tt.filter, tt.sort, tt.field)
+
+			if result.Groups == nil {
+				t.Fatalf("GetGroupedCars() groups = nil, want an empty list")
+			}
+			var keys []string
+			for i, group := range result.Groups {
+				keys = append(keys, group.Key)
+				if i < len(tt.wantIDs) && !slices.Equal(carIDs(group.Cars), tt.wantIDs[i]) {
+					t.Errorf("group %q cars = %v, want %v", group.Key, carIDs(group.Cars), tt.wantIDs[i])
+				}
+			}
+			if !slices.Equal(keys, tt.wantKeys) {
+				t.Errorf("GetGroupedCars() keys = %v, want %v", keys, tt.wantKeys)
+			}
+		})
+	}
+}
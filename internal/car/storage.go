@@ -10,32 +10,40 @@ var (
 	ErrNotFound = errors.New("car not found")
 	// ErrInvalidID is returned when an invalid ID is provided
 	ErrInvalidID = errors.New("invalid id")
+	// ErrConflict is returned when a car with the same ID already exists
+	ErrConflict = errors.New("car with this ID already exists")
+	// ErrDuplicateVIN is returned when another car in the tenant has the same VIN
+	ErrDuplicateVIN = errors.New("car with this VIN already exists")
 )
 
-// Repository defines the interface for car data access
+// Repository defines the interface for car data access. Every operation is
+// scoped to a tenant; cars belonging to other tenants are never visible.
 type Repository interface {
-	Get(id string) (Car, error)
-	GetAll() []Car
+	Get(id, tenantID string) (Car, error)
+	GetByVIN(vin, tenantID string) (Car, error)
+	GetAll(tenantID string) []Car
 	Create(car Car) (Car, error)
 	Update(car Car) (Car, error)
-	Delete(id string) error
+	Delete(id, tenantID string) error
 }
 
-// InMemoryRepository implements Repository interface with an in-memory data store
+// InMemoryRepository implements Repository interface with an in-memory data
+// store keyed by tenant and then by car ID, so IDs only need to be unique
+// within a tenant
 type InMemoryRepository struct {
-	cars map[string]Car
+	cars map[string]map[string]Car
 	mu   sync.RWMutex
 }
 
 // NewInMemoryRepository creates a new in-memory repository
 func NewInMemoryRepository() *InMemoryRepository {
 	return &InMemoryRepository{
-		cars: make(map[string]Car),
+		cars: make(map[string]map[string]Car),
 	}
 }
 
-// Get retrieves a car by ID
-func (r *InMemoryRepository) Get(id string) (Car, error) {
+// Get retrieves a tenant's car by ID
+func (r *InMemoryRepository) Get(id, tenantID string) (Car, error) {
 	if id == "" {
 		return Car{}, ErrInvalidID
 	}
@@ -43,26 +51,53 @@ func (r *InMemoryRepository) Get(id string) (Car, error) {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
 
-	car, ok := r.cars[id]
+	car, ok := r.cars[tenantID][id]
 	if !ok {
 		return Car{}, ErrNotFound
 	}
 	return car, nil
 }
 
-// GetAll retrieves all cars
-func (r *InMemoryRepository) GetAll() []Car {
+// GetByVIN retrieves a tenant's car by VIN
+func (r *InMemoryRepository) GetByVIN(vin, tenantID string) (Car, error) {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
 
-	cars := make([]Car, 0, len(r.cars))
-	for _, car := range r.cars {
+	if car, ok := r.findVINLocked(vin, tenantID, ""); ok {
+		return car, nil
+	}
+	return Car{}, ErrNotFound
+}
+
+// findVINLocked looks for a tenant's live car with the given VIN other than
+// the car with excludeID. Soft-deleted cars don't hold on to their VIN. The
+// caller must hold the lock.
+func (r *InMemoryRepository) findVINLocked(vin, tenantID, excludeID string) (Car, bool) {
+	if vin == "" {
+		return Car{}, false
+	}
+	for id, car := range r.cars[tenantID] {
+		if id != excludeID && car.VIN == vin && !car.IsDeleted() {
+			return car, true
+		}
+	}
+	return Car{}, false
+}
+
+// GetAll retrieves all of a tenant's cars
+func (r *InMemoryRepository) GetAll(tenantID string) []Car {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	cars := make([]Car, 0, len(r.cars[tenantID]))
+	for _, car := range r.cars[tenantID] {
 		cars = append(cars, car)
 	}
 	return cars
 }
 
-// Create adds a new car to the repository
+// Create adds a new car to the repository. A soft-deleted car with the same
+// ID is replaced, so deleted IDs can be reused.
 func (r *InMemoryRepository) Create(car Car) (Car, error) {
 	if car.ID == "" {
 		return Car{}, ErrInvalidID
@@ -71,12 +106,21 @@ func (r *InMemoryRepository) Create(car Car) (Car, error) {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
+	tenantCars, ok := r.cars[car.TenantID]
+	if !ok {
+		tenantCars = make(map[string]Car)
+		r.cars[car.TenantID] = tenantCars
+	}
+
 	// Check if car already exists
-	if _, exists := r.cars[car.ID]; exists {
-		return Car{}, errors.New("car with this ID already exists")
+	if existing, exists := tenantCars[car.ID]; exists && !existing.IsDeleted() {
+		return Car{}, ErrConflict
+	}
+	if _, exists := r.findVINLocked(car.VIN, car.TenantID, ""); exists {
+		return Car{}, ErrDuplicateVIN
 	}
 
-	r.cars[car.ID] = car
+	tenantCars[car.ID] = car
 	return car, nil
 }
 
@@ -89,17 +133,20 @@ func (r *InMemoryRepository) Update(car Car) (Car, error) {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
-	// Check if car exists
-	if _, exists := r.cars[car.ID]; !exists {
+	// Check if car exists for this tenant
+	if _, exists := r.cars[car.TenantID][car.ID]; !exists {
 		return Car{}, ErrNotFound
 	}
+	if _, exists := r.findVINLocked(car.VIN, car.TenantID, car.ID); exists {
+		return Car{}, ErrDuplicateVIN
+	}
 
-	r.cars[car.ID] = car
+	r.cars[car.TenantID][car.ID] = car
 	return car, nil
 }
 
-// Delete removes a car from the repository
-func (r *InMemoryRepository) Delete(id string) error {
+// Delete removes a tenant's car from the repository
+func (r *InMemoryRepository) Delete(id, tenantID string) error {
 	if id == "" {
 		return ErrInvalidID
 	}
@@ -107,11 +154,11 @@ func (r *InMemoryRepository) Delete(id string) error {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
-	// Check if car exists
-	if _, exists := r.cars[id]; !exists {
+	// Check if car exists for this tenant
+	if _, exists := r.cars[tenantID][id]; !exists {
 		return ErrNotFound
 	}
 
-	delete(r.cars, id)
+	delete(r.cars[tenantID], id)
 	return nil
 }
@@ -2,13 +2,15 @@ package car
 
 import (
 	"testing"
+
+	"github.com/joshbarros/golang-carflow-api/internal/timestamp"
 )
 
 func TestInMemoryRepository_GetAll(t *testing.T) {
 	repo := NewInMemoryRepository()
 
 	// Initially, repository should be empty
-	cars := repo.GetAll()
+	cars := repo.GetAll("")
 	if len(cars) != 0 {
 		t.Errorf("Expected empty repository, got %d cars", len(cars))
 	}
@@ -18,7 +20,7 @@ func TestInMemoryRepository_GetAll(t *testing.T) {
 	repo.Create(Car{ID: "2", Make: "Honda", Model: "Civic", Year: 2019, Color: "red"})
 
 	// Now we should have 2 cars
-	cars = repo.GetAll()
+	cars = repo.GetAll("")
 	if len(cars) != 2 {
 		t.Errorf("Expected 2 cars, got %d", len(cars))
 	}
@@ -32,7 +34,7 @@ func TestInMemoryRepository_Get(t *testing.T) {
 	repo.Create(testCar)
 
 	// Test successful retrieval
-	car, err := repo.Get("test1")
+	car, err := repo.Get("test1", "")
 	if err != nil {
 		t.Errorf("Expected no error, got %v", err)
 	}
@@ -41,13 +43,13 @@ func TestInMemoryRepository_Get(t *testing.T) {
 	}
 
 	// Test non-existent car
-	_, err = repo.Get("nonexistent")
+	_, err = repo.Get("nonexistent", "")
 	if err != ErrNotFound {
 		t.Errorf("Expected ErrNotFound for nonexistent car, got %v", err)
 	}
 
 	// Test empty ID
-	_, err = repo.Get("")
+	_, err = repo.Get("", "")
 	if err != ErrInvalidID {
 		t.Errorf("Expected ErrInvalidID for empty ID, got %v", err)
 	}
@@ -67,8 +69,8 @@ func TestInMemoryRepository_Create(t *testing.T) {
 
 	// Test duplicate ID
 	_, err = repo.Create(Car{ID: "1", Make: "Dodge", Model: "Charger", Year: 2020, Color: "green"})
-	if err == nil {
-		t.Error("Expected error when creating car with duplicate ID, got nil")
+	if err != ErrConflict {
+		t.Errorf("Expected ErrConflict when creating car with duplicate ID, got %v", err)
 	}
 
 	// Test empty ID
@@ -95,7 +97,7 @@ func TestInMemoryRepository_Update(t *testing.T) {
 	}
 
 	// Verify the update by getting the car
-	retrievedCar, _ := repo.Get("update1")
+	retrievedCar, _ := repo.Get("update1", "")
 	if retrievedCar.Year != 2021 || retrievedCar.Color != "silver" {
 		t.Errorf("Updated car not found in repository: %v", retrievedCar)
 	}
@@ -112,3 +114,88 @@ func TestInMemoryRepository_Update(t *testing.T) {
 		t.Errorf("Expected ErrInvalidID for empty ID, got %v", err)
 	}
 }
+
+func TestInMemoryRepository_TenantIsolation(t *testing.T) {
+	repo := NewInMemoryRepository()
+
+	// The same ID can exist independently in two tenants
+	if _, err := repo.Create(Car{ID: "shared", TenantID: "a", Make: "Ford", Model: "Focus", Year: 2020}); err != nil {
+		t.Fatalf("Create in tenant a: %v", err)
+	}
+	if _, err := repo.Create(Car{ID: "shared", TenantID: "b", Make: "Kia", Model: "Rio", Year: 2018}); err != nil {
+		t.Fatalf("Create in tenant b: %v", err)
+	}
+	repo.Create(Car{ID: "only-a", TenantID: "a", Make: "Ford", Model: "Fiesta", Year: 2019})
+
+	tests := []struct {
+		name     string
+		id       string
+		tenantID string
+		wantErr  error
+		wantMake string
+	}{
+		{"own car in tenant a", "shared", "a", nil, "Ford"},
+		{"own car in tenant b", "shared", "b", nil, "Kia"},
+		{"other tenant's car", "only-a", "b", ErrNotFound, ""},
+		{"unknown tenant", "shared", "c", ErrNotFound, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			car, err := repo.Get(tt.id, tt.tenantID)
+			if err != tt.wantErr {
+				t.Errorf("Get() error = %v, want %v", err, tt.wantErr)
+			}
+			if car.Make != tt.wantMake {
+				t.Errorf("Get() make = %q, want %q", car.Make, tt.wantMake)
+			}
+		})
+	}
+
+	if got := len(repo.GetAll("b")); got != 1 {
+		t.Errorf("GetAll(b) returned %d cars, want 1", got)
+	}
+
+	// Cross-tenant updates and deletes fail and leave the car untouched
+	if _, err := repo.Update(Car{ID: "only-a", TenantID: "b", Make: "Kia", Model: "Rio", Year: 2018}); err != ErrNotFound {
+		t.Errorf("cross-tenant Update() error = %v, want ErrNotFound", err)
+	}
+	if err := repo.Delete("only-a", "b"); err != ErrNotFound {
+		t.Errorf("cross-tenant Delete() error = %v, want ErrNotFound", err)
+	}
+	if car, err := repo.Get("only-a", "a"); err != nil || car.Model != "Fiesta" {
+		t.Errorf("Get() after cross-tenant writes = %v, %v, want the original car", car, err)
+	}
+
+	// Deleting in one tenant doesn't affect the same ID in another
+	if err := repo.Delete("shared", "a"); err != nil {
+		t.Fatalf("Delete in tenant a: %v", err)
+	}
+	if _, err := repo.Get("shared", "b"); err != nil {
+		t.Errorf("Get() in tenant b after delete in tenant a error = %v", err)
+	}
+}
+
+func TestInMemoryRepository_SoftDeletedCarsFreeIDAndVIN(t *testing.T) {
+	const vin = "1HGCM82633A004352"
+
+	repo := NewInMemoryRepository()
+	deletedAt := timestamp.Now()
+	repo.Create(Car{ID: "c1", TenantID: "t1", Make: "Honda", Model: "Accord", Year: 2003, VIN: vin, DeletedAt: &deletedAt})
+
+	if _, err := repo.GetByVIN(vin, "t1"); err != ErrNotFound {
+		t.Errorf("GetByVIN() for a deleted car error = %v, want ErrNotFound", err)
+	}
+	if _, err := repo.Create(Car{ID: "c2", TenantID: "t1", Make: "Honda", Model: "Civic", Year: 2010, VIN: vin}); err != nil {
+		t.Errorf("Create() reusing a deleted car's VIN error = %v, want nil", err)
+	}
+	if _, err := repo.Create(Car{ID: "c1", TenantID: "t1", Make: "Kia", Model: "Rio", Year: 2020}); err != nil {
+		t.Errorf("Create() reusing a deleted car's ID error = %v, want nil", err)
+	}
+	if car, _ := repo.Get("c1", "t1"); car.IsDeleted() || car.Make != "Kia" {
+		t.Errorf("Get() = %+v, want the new car replacing the deleted one", car)
+	}
+	if _, err := repo.Create(Car{ID: "c1", TenantID: "t1", Make: "Kia", Model: "Rio", Year: 2020}); err != ErrConflict {
+		t.Errorf("Create() with a live car's ID error = %v, want ErrConflict", err)
+	}
+}
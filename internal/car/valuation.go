@@ -0,0 +1,215 @@
+package car
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrValuationUnavailable is returned when the valuation provider couldn't
+// produce an estimate
+var ErrValuationUnavailable = errors.New("valuation unavailable")
+
+// ValuationDisclaimer accompanies every estimate
+const ValuationDisclaimer = "Estimate only, based on limited car data. It is not an offer, appraisal or guarantee of market value."
+
+// Valuation methods
+const (
+	MethodDepreciation = "depreciation"
+	MethodExternal     = "external"
+)
+
+// Valuation providers
+const (
+	ProviderDepreciation = MethodDepreciation
+	ProviderHTTP         = "http"
+)
+
+// ValuationProviders lists the providers accepted by NewValuator
+var ValuationProviders = []string{ProviderDepreciation, ProviderHTTP}
+
+// valuationCacheTTL bounds how long a day's valuation is cached; the cache
+// key also changes with the date
+const valuationCacheTTL = 24 * time.Hour
+
+// valuationTimeout bounds calls to an external valuation API
+const valuationTimeout = 5 * time.Second
+
+// Valuation is an estimated market value for a car
+type Valuation struct {
+	CarID      string `json:"car_id"`
+	Value      int64  `json:"value"`
+	Currency   string `json:"currency"`
+	Method     string `json:"method"`
+	Date       string `json:"date"`
+	Disclaimer string `json:"disclaimer"`
+}
+
+// Valuator estimates what a car is worth on a given day. Implementations set
+// Value, Currency and Method; the service fills in the rest.
+type Valuator interface {
+	Value(ctx context.Context, car Car, on time.Time) (Valuation, error)
+}
+
+// DefaultBasePrice is the new-car price assumed for makes without one in
+// basePrices
+const DefaultBasePrice = 30000
+
+// basePrices holds rough new-car prices in USD by lower-cased make
+var basePrices = map[string]int64{
+	"audi":          50000,
+	"bmw":           55000,
+	"chevrolet":     32000,
+	"ford":          32000,
+	"honda":         28000,
+	"hyundai":       26000,
+	"kia":           25000,
+	"lexus":         48000,
+	"mercedes-benz": 55000,
+	"nissan":        27000,
+	"porsche":       90000,
+	"tesla":         50000,
+	"toyota":        30000,
+	"volkswagen":    29000,
+}
+
+// DepreciationValuator estimates values without any external dependency: a
+// base price by make loses 15% per year of age, down to 10% of the base
+type DepreciationValuator struct{}
+
+// Value returns the depreciated value of car on the given day, rounded to
+// the nearest hundred dollars
+func (DepreciationValuator) Value(ctx context.Context, car Car, on time.Time) (Valuation, error) {
+	base, ok := basePrices[strings.ToLower(car.Make)]
+	if !ok {
+		base = DefaultBasePrice
+	}
+
+	age := max(on.Year()-car.Year, 0)
+	value := max(float64(base)*math.Pow(0.85, float64(age)), float64(base)*0.1)
+
+	return Valuation{
+		Value:    int64(math.Round(value/100) * 100),
+		Currency: "USD",
+		Method:   MethodDepreciation,
+	}, nil
+}
+
+// HTTPValuator asks an external API for estimates. It sends
+// GET <url>?make=&model=&year=&vin= and expects
+// {"value": <number>, "currency": "<code>"} in return.
+type HTTPValuator struct {
+	URL    string
+	Client *http.Client
+}
+
+// Value fetches the estimate for car from the external API
+func (v HTTPValuator) Value(ctx context.Context, car Car, on time.Time) (Valuation, error) {
+	query := url.Values{}
+	query.Set("make", car.Make)
+	query.Set("model", car.Model)
+	query.Set("year", strconv.Itoa(car.Year))
+	if car.VIN != "" {
+		query.Set("vin", car.VIN)
+	}
+
+	target := v.URL + "?" + query.Encode()
+	if strings.Contains(v.URL, "?") {
+		target = v.URL + "&" + query.Encode()
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+	if err != nil {
+		return Valuation{}, err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := v.Client.Do(req)
+	if err != nil {
+		return Valuation{}, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return Valuation{}, fmt.Errorf("valuation API returned %s", resp.Status)
+	}
+
+	var body struct {
+		Value    *float64 `json:"value"`
+		Currency string   `json:"currency"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return Valuation{}, fmt.Errorf("decoding valuation API response: %w", err)
+	}
+	if body.Value == nil || *body.Value < 0 {
+		return Valuation{}, errors.New("valuation API returned no value")
+	}
+	if body.Currency == "" {
+		body.Currency = "USD"
+	}
+
+	return Valuation{
+		Value:    int64(math.Round(*body.Value)),
+		Currency: body.Currency,
+		Method:   MethodExternal,
+	}, nil
+}
+
+// NewValuator returns the valuator for the named provider. The "http"
+// provider calls the API at apiURL.
+func NewValuator(provider, apiURL string) (Valuator, error) {
+	switch provider {
+	case "", ProviderDepreciation:
+		return DepreciationValuator{}, nil
+	case ProviderHTTP:
+		if apiURL == "" {
+			return nil, errors.New("the http valuation provider needs an API URL")
+		}
+		return HTTPValuator{URL: apiURL, Client: &http.Client{Timeout: valuationTimeout}}, nil
+	default:
+		return nil, fmt.Errorf("unknown valuation provider %q (allowed: %s)", provider, strings.Join(ValuationProviders, ", "))
+	}
+}
+
+// ValueCar estimates the value of a tenant's car as of now. Estimates are
+// cached per car and day when the service has a cache.
+func (s *Service) ValueCar(ctx context.Context, id, tenantID string, now time.Time) (Valuation, error) {
+	car, err := s.GetCar(id, tenantID)
+	if err != nil {
+		return Valuation{}, err
+	}
+
+	date := now.UTC().Format(time.DateOnly)
+	compute := func() (interface{}, error) {
+		valuation, err := s.valuator.Value(ctx, car, now)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrValuationUnavailable, err)
+		}
+		valuation.CarID = car.ID
+		valuation.Date = date
+		valuation.Disclaimer = ValuationDisclaimer
+		return valuation, nil
+	}
+
+	var valuation interface{}
+	if s.cache == nil {
+		valuation, err = compute()
+	} else {
+		valuation, err = s.cache.GetOrSet(valuationKey(id, tenantID, date), valuationCacheTTL, compute)
+	}
+	if err != nil {
+		return Valuation{}, err
+	}
+	return valuation.(Valuation), nil
+}
+
+// valuationKey returns the cache key for a car's valuation on a date
+func valuationKey(id, tenantID, date string) string {
+	return "valuation:" + tenantID + ":" + id + ":" + date
+}
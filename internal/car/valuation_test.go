@@ -0,0 +1,112 @@
+package car
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/joshbarros/golang-carflow-api/internal/cache"
+)
+
+func TestDepreciationValuator(t *testing.T) {
+	on := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name string
+		car  Car
+		want int64
+	}{
+		{"new car", Car{Make: "Toyota", Year: 2024}, 30000},
+		{"next model year", Car{Make: "Toyota", Year: 2025}, 30000},
+		{"two years old", Car{Make: "toyota", Year: 2022}, 21700},
+		{"unknown make", Car{Make: "Lada", Year: 2023}, 25500},
+		{"floor", Car{Make: "Porsche", Year: 1970}, 9000},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := DepreciationValuator{}.Value(context.Background(), tt.car, on)
+			if err != nil {
+				t.Fatalf("Value() error = %v", err)
+			}
+			if got.Value != tt.want || got.Currency != "USD" || got.Method != MethodDepreciation {
+				t.Errorf("Value() = %+v, want %d USD by %s", got, tt.want, MethodDepreciation)
+			}
+		})
+	}
+}
+
+func TestHTTPValuator(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("make") != "Honda" || r.URL.Query().Get("year") != "2003" {
+			http.Error(w, "bad query", http.StatusBadRequest)
+			return
+		}
+		w.Write([]byte(`{"value": 4321.4, "currency": "EUR"}`))
+	}))
+	defer server.Close()
+
+	v, err := NewValuator("http", server.URL)
+	if err != nil {
+		t.Fatalf("NewValuator() error = %v", err)
+	}
+
+	got, err := v.Value(context.Background(), Car{Make: "Honda", Model: "Accord", Year: 2003}, time.Now())
+	if err != nil {
+		t.Fatalf("Value() error = %v", err)
+	}
+	if got.Value != 4321 || got.Currency != "EUR" || got.Method != MethodExternal {
+		t.Errorf("Value() = %+v, want 4321 EUR by %s", got, MethodExternal)
+	}
+
+	if _, err := v.Value(context.Background(), Car{Make: "Kia", Year: 2003}, time.Now()); err == nil {
+		t.Error("Value() error = nil for a failing API, want an error")
+	}
+}
+
+// countingValuator counts calls and returns a fixed value, or err if set
+type countingValuator struct {
+	calls int
+	err   error
+}
+
+func (v *countingValuator) Value(ctx context.Context, car Car, on time.Time) (Valuation, error) {
+	v.calls++
+	return Valuation{Value: 1000, Currency: "USD", Method: "test"}, v.err
+}
+
+func TestService_ValueCar(t *testing.T) {
+	valuator := &countingValuator{}
+	service := NewService(NewInMemoryRepository(), WithCache(cache.New(0, 0), time.Minute), WithValuator(valuator))
+	service.CreateCar(Car{ID: "c1", TenantID: "t1", Make: "Kia", Model: "Rio", Year: 2020})
+
+	day := time.Date(2024, 6, 1, 9, 0, 0, 0, time.UTC)
+	first, err := service.ValueCar(context.Background(), "c1", "t1", day)
+	if err != nil {
+		t.Fatalf("ValueCar() error = %v", err)
+	}
+	if first.CarID != "c1" || first.Date != "2024-06-01" || first.Disclaimer == "" {
+		t.Errorf("ValueCar() = %+v, want car ID, date and disclaimer filled in", first)
+	}
+
+	service.ValueCar(context.Background(), "c1", "t1", day.Add(time.Hour))
+	if valuator.calls != 1 {
+		t.Errorf("valuator calls = %d after two valuations on one day, want 1", valuator.calls)
+	}
+	service.ValueCar(context.Background(), "c1", "t1", day.AddDate(0, 0, 1))
+	if valuator.calls != 2 {
+		t.Errorf("valuator calls = %d after a valuation the next day, want 2", valuator.calls)
+	}
+
+	if _, err := service.ValueCar(context.Background(), "c1", "t2", day); !errors.Is(err, ErrNotFound) {
+		t.Errorf("ValueCar() for another tenant error = %v, want ErrNotFound", err)
+	}
+
+	valuator.err = errors.New("provider down")
+	if _, err := service.ValueCar(context.Background(), "c1", "t1", day.AddDate(0, 0, 2)); !errors.Is(err, ErrValuationUnavailable) {
+		t.Errorf("ValueCar() with a failing provider error = %v, want ErrValuationUnavailable", err)
+	}
+}
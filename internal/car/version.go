@@ -0,0 +1,112 @@
+package car
+
+import (
+	"fmt"
+	"mime"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/joshbarros/golang-carflow-api/internal/apierror"
+)
+
+// LatestVersion is the response version served when a client doesn't ask
+// for a specific one
+const LatestVersion = 1
+
+// vendorMediaType matches versioned media types such as
+// application/vnd.carflow.v1+json
+var vendorMediaType = regexp.MustCompile(`^application/vnd\.carflow\.v(\d+)\+json$`)
+
+// responseEncoders shapes response payloads for each supported version. When
+// a response format changes, the new shape gets a new version and older
+// versions keep their encoder so existing clients don't break.
+var responseEncoders = map[int]func(payload interface{}) interface{}{
+	1: func(payload interface{}) interface{} { return payload },
+}
+
+// responseVersion is the outcome of negotiating a response version
+type responseVersion struct {
+	version     int
+	contentType string
+}
+
+// negotiateVersion picks the response version from the Accept header. A
+// supported vendor media type selects that version; plain JSON or wildcards
+// get the latest version. The acceptable type with the highest q-value
+// wins, and on a tie an explicit version beats generic JSON. Types with
+// q=0 are ignored. It fails when the header only asks for unsupported
+// versions.
+func negotiateVersion(accept string) (responseVersion, error) {
+	latest := responseVersion{version: LatestVersion, contentType: "application/json"}
+	if accept == "" {
+		return latest, nil
+	}
+
+	var best responseVersion
+	var bestQ float64
+	found, bestSpecific := false, false
+	var unsupported []string
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+
+		q := 1.0
+		if s, ok := params["q"]; ok {
+			if q, err = strconv.ParseFloat(s, 64); err != nil {
+				continue
+			}
+		}
+		if q <= 0 {
+			continue
+		}
+
+		candidate := latest
+		specific := false
+		if m := vendorMediaType.FindStringSubmatch(mediaType); m != nil {
+			version, _ := strconv.Atoi(m[1])
+			if _, ok := responseEncoders[version]; !ok {
+				unsupported = append(unsupported, mediaType)
+				continue
+			}
+			candidate = responseVersion{version: version, contentType: mediaType}
+			specific = true
+		} else if mediaType != "application/json" && mediaType != "application/*" && mediaType != "*/*" {
+			continue
+		}
+
+		if !found || q > bestQ || (q == bestQ && specific && !bestSpecific) {
+			best, bestQ, bestSpecific, found = candidate, q, specific, true
+		}
+	}
+
+	if found {
+		return best, nil
+	}
+	if len(unsupported) > 0 {
+		return responseVersion{}, fmt.Errorf("unsupported response version %s (latest: application/vnd.carflow.v%d+json)",
+			strings.Join(unsupported, ", "), LatestVersion)
+	}
+	return latest, nil
+}
+
+// requireVersion negotiates the response version for r. When no requested
+// version is supported it answers 406 and returns false.
+func requireVersion(w http.ResponseWriter, r *http.Request) (responseVersion, bool) {
+	w.Header().Add("Vary", "Accept")
+
+	v, err := negotiateVersion(r.Header.Get("Accept"))
+	if err != nil {
+		respondWithError(w, http.StatusNotAcceptable, apierror.NotAcceptable, err.Error())
+		return responseVersion{}, false
+	}
+	return v, true
+}
+
+// respondWithVersion writes payload in the negotiated version's format
+func respondWithVersion(w http.ResponseWriter, v responseVersion, code int, payload interface{}) {
+	respondWithJSONType(w, code, v.contentType, responseEncoders[v.version](payload))
+}
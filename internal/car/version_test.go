@@ -0,0 +1,97 @@
+package car
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/joshbarros/golang-carflow-api/internal/tenant"
+)
+
+func TestNegotiateVersion(t *testing.T) {
+	tests := []struct {
+		name            string
+		accept          string
+		wantVersion     int
+		wantContentType string
+		wantErr         bool
+	}{
+		{"no header", "", LatestVersion, "application/json", false},
+		{"plain JSON", "application/json", LatestVersion, "application/json", false},
+		{"wildcard", "*/*", LatestVersion, "application/json", false},
+		{"v1", "application/vnd.carflow.v1+json", 1, "application/vnd.carflow.v1+json", false},
+		{"v1 with parameters", "application/vnd.carflow.v1+json; q=0.9", 1, "application/vnd.carflow.v1+json", false},
+		{"v1 among others", "text/html, application/vnd.carflow.v1+json", 1, "application/vnd.carflow.v1+json", false},
+		{"unknown version", "application/vnd.carflow.v99+json", 0, "", true},
+		{"unknown version with JSON fallback", "application/vnd.carflow.v99+json, application/json", LatestVersion, "application/json", false},
+		{"v1 after JSON", "application/json, application/vnd.carflow.v1+json", 1, "application/vnd.carflow.v1+json", false},
+		{"JSON weighted above v1", "application/vnd.carflow.v1+json;q=0.1, application/json;q=0.5", LatestVersion, "application/json", false},
+		{"v1 refused", "application/vnd.carflow.v1+json;q=0, application/json", LatestVersion, "application/json", false},
+		{"unknown version weighted above JSON", "application/vnd.carflow.v99+json, application/json;q=0.1", LatestVersion, "application/json", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v, err := negotiateVersion(tt.accept)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("negotiateVersion() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if v.version != tt.wantVersion || v.contentType != tt.wantContentType {
+				t.Errorf("negotiateVersion() = %+v, want version %d content type %s", v, tt.wantVersion, tt.wantContentType)
+			}
+		})
+	}
+}
+
+func TestNegotiateVersion_QValues(t *testing.T) {
+	responseEncoders[2] = responseEncoders[1]
+	t.Cleanup(func() { delete(responseEncoders, 2) })
+
+	v, err := negotiateVersion("application/vnd.carflow.v1+json;q=0.1, application/vnd.carflow.v2+json")
+	if err != nil || v.version != 2 {
+		t.Errorf("negotiateVersion() = %+v, %v, want version 2", v, err)
+	}
+}
+
+func TestHandler_VersionedResponses(t *testing.T) {
+	service := NewService(NewInMemoryRepository())
+	service.CreateCar(Car{ID: "ver-1", TenantID: tenant.DefaultID, Make: "Mazda", Model: "3", Year: 2019})
+
+	mux := http.NewServeMux()
+	NewHandler(service).RegisterRoutes(mux)
+
+	tests := []struct {
+		name            string
+		path            string
+		accept          string
+		wantCode        int
+		wantContentType string
+	}{
+		{"list v1", "/cars", "application/vnd.carflow.v1+json", http.StatusOK, "application/vnd.carflow.v1+json"},
+		{"list default", "/cars", "", http.StatusOK, "application/json"},
+		{"list unknown version", "/cars", "application/vnd.carflow.v2+json", http.StatusNotAcceptable, "application/json"},
+		{"get v1", "/cars/ver-1", "application/vnd.carflow.v1+json", http.StatusOK, "application/vnd.carflow.v1+json"},
+		{"get unknown version", "/cars/missing", "application/vnd.carflow.v2+json", http.StatusNotAcceptable, "application/json"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			if tt.accept != "" {
+				req.Header.Set("Accept", tt.accept)
+			}
+			rec := httptest.NewRecorder()
+			mux.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantCode {
+				t.Errorf("status = %d, want %d", rec.Code, tt.wantCode)
+			}
+			if got := rec.Header().Get("Content-Type"); got != tt.wantContentType {
+				t.Errorf("Content-Type = %q, want %q", got, tt.wantContentType)
+			}
+			if rec.Header().Get("Vary") != "Accept" {
+				t.Errorf("Vary = %q, want Accept", rec.Header().Get("Vary"))
+			}
+		})
+	}
+}
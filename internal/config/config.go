@@ -0,0 +1,327 @@
+package config
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/joshbarros/golang-carflow-api/internal/car"
+	"github.com/joshbarros/golang-carflow-api/internal/metrics"
+	"github.com/joshbarros/golang-carflow-api/internal/middleware"
+	"github.com/joshbarros/golang-carflow-api/internal/tenant"
+)
+
+const (
+	// EnvDevelopment is the default environment
+	EnvDevelopment = "development"
+	// EnvProduction enables the stricter startup checks
+	EnvProduction = "production"
+)
+
+const (
+	// CacheModeInvalidate drops a car from the cache when it is written
+	CacheModeInvalidate = "invalidate"
+	// CacheModeWriteThrough stores written cars in the cache
+	CacheModeWriteThrough = "write-through"
+)
+
+// Config holds the application configuration
+type Config struct {
+	Environment string
+	AdminToken  string
+
+	TenantHeaderMode string
+
+	Port              int
+	RateLimit         int
+	RateLimitUnit     string
+	RateBurst         int
+	RateSoftThreshold float64
+
+	CORSAllowedOrigins []string
+
+	MaxURLLength        int
+	MaxQueryParamLength int
+
+	ValidationErrorStatus int
+
+	MaxBatchSize int
+
+	CarYearMin int
+	CarYearMax int
+
+	CacheTTL      time.Duration
+	CacheMaxItems int
+	CacheMode     string
+
+	ChaosEnabled  bool
+	ChaosMaxDelay time.Duration
+
+	ValuationProvider string
+	ValuationAPIURL   string
+
+	CarIDStrategy        string
+	CarIDPrefix          string
+	CarIDSequenceTenants []string
+
+	MetricsResponseTimesSize int
+	MetricsLastRequestsSize  int
+}
+
+// Load reads the configuration from environment variables and command-line
+// flags, with flags taking precedence, and validates the result
+func Load(args []string) (*Config, error) {
+	var errs []error
+
+	cfg := &Config{
+		Environment:              getEnv("APP_ENV", EnvDevelopment),
+		AdminToken:               getEnv("ADMIN_TOKEN", ""),
+		TenantHeaderMode:         getEnv("TENANT_HEADER_MODE", tenant.ModeTrust),
+		Port:                     getEnvInt("PORT", 8080, &errs),
+		RateLimit:                getEnvInt("RATE_LIMIT", 100, &errs),
+		RateLimitUnit:            getEnv("RATE_LIMIT_UNIT", middleware.UnitSecond),
+		RateBurst:                getEnvInt("RATE_BURST", 20, &errs),
+		RateSoftThreshold:        getEnvFloat("RATE_LIMIT_SOFT_THRESHOLD", 0.8, &errs),
+		CORSAllowedOrigins:       getEnvList("CORS_ALLOWED_ORIGINS", []string{"*"}),
+		MaxURLLength:             getEnvInt("MAX_URL_LENGTH", 2048, &errs),
+		MaxQueryParamLength:      getEnvInt("MAX_QUERY_PARAM_LENGTH", 256, &errs),
+		ValidationErrorStatus:    getEnvInt("VALIDATION_ERROR_STATUS", 400, &errs),
+		MaxBatchSize:             getEnvInt("MAX_BATCH_SIZE", car.DefaultMaxBatchSize, &errs),
+		CarYearMin:               getEnvInt("CAR_YEAR_MIN", car.DefaultMinYear, &errs),
+		CarYearMax:               getEnvInt("CAR_YEAR_MAX", 0, &errs),
+		CacheTTL:                 getEnvDuration("CACHE_TTL", 5*time.Minute, &errs),
+		CacheMaxItems:            getEnvInt("CACHE_MAX_ITEMS", 10000, &errs),
+		CacheMode:                getEnv("CACHE_MODE", CacheModeInvalidate),
+		ChaosEnabled:             getEnvBool("CHAOS_ENABLED", false, &errs),
+		ChaosMaxDelay:            getEnvDuration("CHAOS_MAX_DELAY", 5*time.Second, &errs),
+		ValuationProvider:        getEnv("VALUATION_PROVIDER", car.ProviderDepreciation),
+		ValuationAPIURL:          getEnv("VALUATION_API_URL", ""),
+		CarIDStrategy:            getEnv("CAR_ID_STRATEGY", car.IDStrategyClient),
+		CarIDPrefix:              getEnv("CAR_ID_PREFIX", "CAR-"),
+		CarIDSequenceTenants:     getEnvList("CAR_ID_SEQUENCE_TENANTS", nil),
+		MetricsResponseTimesSize: getEnvInt("METRICS_RESPONSE_TIMES_SIZE", metrics.DefaultResponseTimeWindow, &errs),
+		MetricsLastRequestsSize:  getEnvInt("METRICS_LAST_REQUESTS_SIZE", metrics.DefaultLastRequestsWindow, &errs),
+	}
+
+	// Parse command-line flags
+	fs := flag.NewFlagSet("carflow", flag.ContinueOnError)
+	fs.IntVar(&cfg.Port, "port", cfg.Port, "Port to listen on")
+	fs.IntVar(&cfg.RateLimit, "rate-limit", cfg.RateLimit, "Rate limit in requests per RATE_LIMIT_UNIT")
+	fs.IntVar(&cfg.RateBurst, "rate-burst", cfg.RateBurst, "Maximum burst size for rate limiting")
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+
+	errs = append(errs, cfg.Validate())
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+
+	return cfg, nil
+}
+
+// Validate checks that all configuration values are usable
+func (c *Config) Validate() error {
+	var errs []error
+
+	if c.Environment != EnvDevelopment && c.Environment != EnvProduction {
+		errs = append(errs, fmt.Errorf("APP_ENV must be %q or %q, got %q", EnvDevelopment, EnvProduction, c.Environment))
+	}
+	if c.IsProduction() && c.AdminToken != "" && len(c.AdminToken) < 32 {
+		errs = append(errs, errors.New("ADMIN_TOKEN must be at least 32 characters in production"))
+	}
+	if !slices.Contains(tenant.Modes, c.TenantHeaderMode) {
+		errs = append(errs, fmt.Errorf("TENANT_HEADER_MODE must be one of %s, got %q", strings.Join(tenant.Modes, ", "), c.TenantHeaderMode))
+	}
+	if c.TenantHeaderMode == tenant.ModeAdminOnly && c.AdminToken == "" {
+		errs = append(errs, errors.New("TENANT_HEADER_MODE admin-only requires ADMIN_TOKEN"))
+	}
+	if c.IsProduction() && c.ChaosEnabled {
+		errs = append(errs, errors.New("CHAOS_ENABLED must not be set in production"))
+	}
+	if c.ChaosMaxDelay <= 0 {
+		errs = append(errs, fmt.Errorf("CHAOS_MAX_DELAY must be positive, got %s", c.ChaosMaxDelay))
+	}
+	if c.Port < 1 || c.Port > 65535 {
+		errs = append(errs, fmt.Errorf("port must be between 1 and 65535, got %d", c.Port))
+	}
+	if c.RateLimit < 1 {
+		errs = append(errs, fmt.Errorf("rate limit must be positive, got %d", c.RateLimit))
+	}
+	if !slices.Contains(middleware.RateLimitUnits, c.RateLimitUnit) {
+		errs = append(errs, fmt.Errorf("RATE_LIMIT_UNIT must be one of %s, got %q", strings.Join(middleware.RateLimitUnits, ", "), c.RateLimitUnit))
+	}
+	if c.RateBurst < 1 {
+		errs = append(errs, fmt.Errorf("rate burst must be positive, got %d", c.RateBurst))
+	}
+	if c.RateSoftThreshold < 0 || c.RateSoftThreshold > 1 {
+		errs = append(errs, fmt.Errorf("RATE_LIMIT_SOFT_THRESHOLD must be between 0 and 1, got %v", c.RateSoftThreshold))
+	}
+	if c.MaxURLLength < 256 {
+		errs = append(errs, fmt.Errorf("MAX_URL_LENGTH must be at least 256, got %d", c.MaxURLLength))
+	}
+	if c.MaxQueryParamLength < 1 || c.MaxQueryParamLength > c.MaxURLLength {
+		errs = append(errs, fmt.Errorf("MAX_QUERY_PARAM_LENGTH must be between 1 and MAX_URL_LENGTH, got %d", c.MaxQueryParamLength))
+	}
+	if c.ValidationErrorStatus != 400 && c.ValidationErrorStatus != 422 {
+		errs = append(errs, fmt.Errorf("VALIDATION_ERROR_STATUS must be 400 or 422, got %d", c.ValidationErrorStatus))
+	}
+	if c.MaxBatchSize < 1 {
+		errs = append(errs, fmt.Errorf("MAX_BATCH_SIZE must be at least 1, got %d", c.MaxBatchSize))
+	}
+	if c.CarYearMin < 1 {
+		errs = append(errs, fmt.Errorf("CAR_YEAR_MIN must be positive, got %d", c.CarYearMin))
+	}
+	if c.CarYearMax != 0 && c.CarYearMax < c.CarYearMin {
+		errs = append(errs, fmt.Errorf("CAR_YEAR_MAX must be 0 (next year) or at least CAR_YEAR_MIN, got %d", c.CarYearMax))
+	}
+	if c.CacheTTL < 0 {
+		errs = append(errs, fmt.Errorf("CACHE_TTL must not be negative, got %s", c.CacheTTL))
+	}
+	if c.CacheMaxItems < 0 {
+		errs = append(errs, fmt.Errorf("CACHE_MAX_ITEMS must not be negative, got %d", c.CacheMaxItems))
+	}
+	if c.CacheMode != CacheModeInvalidate && c.CacheMode != CacheModeWriteThrough {
+		errs = append(errs, fmt.Errorf("CACHE_MODE must be %q or %q, got %q", CacheModeInvalidate, CacheModeWriteThrough, c.CacheMode))
+	}
+	if !slices.Contains(car.ValuationProviders, c.ValuationProvider) {
+		errs = append(errs, fmt.Errorf("VALUATION_PROVIDER must be one of %s, got %q", strings.Join(car.ValuationProviders, ", "), c.ValuationProvider))
+	}
+	if c.ValuationProvider == car.ProviderHTTP && c.ValuationAPIURL == "" {
+		errs = append(errs, errors.New("VALUATION_API_URL is required when VALUATION_PROVIDER is http"))
+	}
+	if !slices.Contains(car.IDStrategies, c.CarIDStrategy) {
+		errs = append(errs, fmt.Errorf("CAR_ID_STRATEGY must be one of %s, got %q", strings.Join(car.IDStrategies, ", "), c.CarIDStrategy))
+	}
+	if !car.ValidIDPrefix(c.CarIDPrefix) {
+		errs = append(errs, fmt.Errorf("CAR_ID_PREFIX may only contain letters, digits, dashes and underscores, got %q", c.CarIDPrefix))
+	}
+	if c.MetricsResponseTimesSize < 1 || c.MetricsResponseTimesSize > 100000 {
+		errs = append(errs, fmt.Errorf("METRICS_RESPONSE_TIMES_SIZE must be between 1 and 100000, got %d", c.MetricsResponseTimesSize))
+	}
+	if c.MetricsLastRequestsSize < 1 || c.MetricsLastRequestsSize > 10000 {
+		errs = append(errs, fmt.Errorf("METRICS_LAST_REQUESTS_SIZE must be between 1 and 10000, got %d", c.MetricsLastRequestsSize))
+	}
+
+	return errors.Join(errs...)
+}
+
+// IsProduction reports whether the application runs in production mode
+func (c *Config) IsProduction() bool {
+	return c.Environment == EnvProduction
+}
+
+// Log writes the effective configuration to the standard logger
+func (c *Config) Log() {
+	if c.AdminToken == "" {
+		log.Printf("Config: ADMIN_TOKEN not set, admin endpoints are disabled")
+	}
+	log.Printf("Config: environment=%s tenant_header_mode=%s port=%d rate_limit=%d rate_limit_unit=%s rate_burst=%d rate_soft_threshold=%v",
+		c.Environment, c.TenantHeaderMode, c.Port, c.RateLimit, c.RateLimitUnit, c.RateBurst, c.RateSoftThreshold)
+	log.Printf("Config: cors_allowed_origins=%s max_url_length=%d max_query_param_length=%d",
+		strings.Join(c.CORSAllowedOrigins, ","), c.MaxURLLength, c.MaxQueryParamLength)
+	log.Printf("Config: max_batch_size=%d car_year_min=%d car_year_max=%d cache_ttl=%s cache_max_items=%d cache_mode=%s",
+		c.MaxBatchSize, c.CarYearMin, c.CarYearMax, c.CacheTTL, c.CacheMaxItems, c.CacheMode)
+	log.Printf("Config: validation_error_status=%d car_id_strategy=%s car_id_prefix=%s car_id_sequence_tenants=%s",
+		c.ValidationErrorStatus, c.CarIDStrategy, c.CarIDPrefix, strings.Join(c.CarIDSequenceTenants, ","))
+	log.Printf("Config: valuation_provider=%s", c.ValuationProvider)
+	if c.ChaosEnabled {
+		log.Printf("Config: chaos testing ENABLED, max_delay=%s", c.ChaosMaxDelay)
+	}
+	log.Printf("Config: metrics_response_times_size=%d metrics_last_requests_size=%d",
+		c.MetricsResponseTimesSize, c.MetricsLastRequestsSize)
+}
+
+// getEnv returns the value of an environment variable or a default
+func getEnv(key, defaultValue string) string {
+	if value, ok := os.LookupEnv(key); ok && value != "" {
+		return value
+	}
+	return defaultValue
+}
+
+// getEnvList returns a comma-separated environment variable as a list or a default
+func getEnvList(key string, defaultValue []string) []string {
+	value := getEnv(key, "")
+	if value == "" {
+		return defaultValue
+	}
+
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// getEnvInt returns an integer environment variable or a default, recording
+// an error if the value isn't a number
+func getEnvInt(key string, defaultValue int, errs *[]error) int {
+	value := getEnv(key, "")
+	if value == "" {
+		return defaultValue
+	}
+
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		*errs = append(*errs, fmt.Errorf("%s must be an integer, got %q", key, value))
+		return defaultValue
+	}
+	return n
+}
+
+// getEnvFloat returns a float environment variable or a default, recording
+// an error if the value isn't a number
+func getEnvFloat(key string, defaultValue float64, errs *[]error) float64 {
+	value := getEnv(key, "")
+	if value == "" {
+		return defaultValue
+	}
+
+	f, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		*errs = append(*errs, fmt.Errorf("%s must be a number, got %q", key, value))
+		return defaultValue
+	}
+	return f
+}
+
+// getEnvDuration returns an environment variable parsed as a duration such
+// as "30s" or "5m", or a default
+func getEnvDuration(key string, defaultValue time.Duration, errs *[]error) time.Duration {
+	value := getEnv(key, "")
+	if value == "" {
+		return defaultValue
+	}
+
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		*errs = append(*errs, fmt.Errorf("%s must be a duration such as 30s or 5m, got %q", key, value))
+		return defaultValue
+	}
+	return d
+}
+
+// getEnvBool returns an environment variable parsed as a boolean, or a
+// default
+func getEnvBool(key string, defaultValue bool, errs *[]error) bool {
+	value := getEnv(key, "")
+	if value == "" {
+		return defaultValue
+	}
+
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		*errs = append(*errs, fmt.Errorf("%s must be true or false, got %q", key, value))
+		return defaultValue
+	}
+	return b
+}
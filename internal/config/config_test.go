@@ -0,0 +1,120 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestLoad_Defaults(t *testing.T) {
+	t.Setenv("PORT", "")
+
+	cfg, err := Load(nil)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if cfg.Port != 8080 || cfg.RateLimit != 100 || cfg.RateBurst != 20 {
+		t.Errorf("Load() = %+v, want default port and rate limits", cfg)
+	}
+	if cfg.Environment != EnvDevelopment || cfg.IsProduction() {
+		t.Errorf("Load() environment = %q, want %q", cfg.Environment, EnvDevelopment)
+	}
+}
+
+func TestLoad_FlagsOverrideEnv(t *testing.T) {
+	t.Setenv("PORT", "9090")
+	t.Setenv("RATE_LIMIT", "50")
+
+	cfg, err := Load([]string{"-port", "7070"})
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if cfg.Port != 7070 {
+		t.Errorf("Load() port = %d, want 7070", cfg.Port)
+	}
+	if cfg.RateLimit != 50 {
+		t.Errorf("Load() rate limit = %d, want 50", cfg.RateLimit)
+	}
+}
+
+func TestLoad_InvalidValues(t *testing.T) {
+	t.Setenv("APP_ENV", "staging")
+	t.Setenv("RATE_BURST", "lots")
+	t.Setenv("METRICS_LAST_REQUESTS_SIZE", "0")
+	t.Setenv("CAR_YEAR_MIN", "1950")
+	t.Setenv("CAR_YEAR_MAX", "1900")
+	t.Setenv("CACHE_TTL", "5")
+	t.Setenv("VALUATION_PROVIDER", "http")
+	t.Setenv("CACHE_MODE", "write-back")
+
+	_, err := Load(nil)
+	if err == nil {
+		t.Fatal("Load() expected error for invalid configuration")
+	}
+
+	for _, want := range []string{"APP_ENV", "RATE_BURST", "METRICS_LAST_REQUESTS_SIZE", "CAR_YEAR_MAX", "CACHE_TTL", "VALUATION_API_URL", "CACHE_MODE"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("Load() error = %v, expected it to mention %s", err, want)
+		}
+	}
+}
+
+func TestLoad_ChaosRejectedInProduction(t *testing.T) {
+	t.Setenv("APP_ENV", EnvProduction)
+	t.Setenv("CHAOS_ENABLED", "true")
+
+	_, err := Load(nil)
+	if err == nil || !strings.Contains(err.Error(), "CHAOS_ENABLED") {
+		t.Errorf("Load() error = %v, want CHAOS_ENABLED rejected in production", err)
+	}
+
+	t.Setenv("APP_ENV", EnvDevelopment)
+	cfg, err := Load(nil)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if !cfg.ChaosEnabled {
+		t.Errorf("Load() ChaosEnabled = false, want true in development")
+	}
+}
+
+func TestLoad_TenantHeaderMode(t *testing.T) {
+	t.Setenv("TENANT_HEADER_MODE", "open")
+
+	_, err := Load(nil)
+	if err == nil || !strings.Contains(err.Error(), "TENANT_HEADER_MODE") {
+		t.Errorf("Load() error = %v, want unknown TENANT_HEADER_MODE rejected", err)
+	}
+
+	t.Setenv("TENANT_HEADER_MODE", "admin-only")
+	_, err = Load(nil)
+	if err == nil || !strings.Contains(err.Error(), "ADMIN_TOKEN") {
+		t.Errorf("Load() error = %v, want admin-only rejected without ADMIN_TOKEN", err)
+	}
+
+	t.Setenv("ADMIN_TOKEN", "secret")
+	cfg, err := Load(nil)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if cfg.TenantHeaderMode != "admin-only" {
+		t.Errorf("Load() TenantHeaderMode = %q, want admin-only", cfg.TenantHeaderMode)
+	}
+}
+
+func TestLoad_CarIDPrefix(t *testing.T) {
+	t.Setenv("CAR_ID_PREFIX", "CAR/")
+
+	_, err := Load(nil)
+	if err == nil || !strings.Contains(err.Error(), "CAR_ID_PREFIX") {
+		t.Errorf("Load() error = %v, want CAR_ID_PREFIX with a slash rejected", err)
+	}
+
+	t.Setenv("CAR_ID_PREFIX", "fleet_")
+	cfg, err := Load(nil)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if cfg.CarIDPrefix != "fleet_" {
+		t.Errorf("Load() CarIDPrefix = %q, want fleet_", cfg.CarIDPrefix)
+	}
+}
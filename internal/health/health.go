@@ -3,7 +3,10 @@ package health
 import (
 	"encoding/json"
 	"net/http"
+	"runtime"
 	"time"
+
+	"github.com/joshbarros/golang-carflow-api/internal/timestamp"
 )
 
 // Handler is a health check handler
@@ -18,17 +21,45 @@ func NewHandler() *Handler {
 	}
 }
 
+// Mux registers routes, as *http.ServeMux does
+type Mux interface {
+	HandleFunc(pattern string, handler func(http.ResponseWriter, *http.Request))
+}
+
 // RegisterRoutes registers the health check routes
-func (h *Handler) RegisterRoutes(mux *http.ServeMux) {
+func (h *Handler) RegisterRoutes(mux Mux) {
 	mux.HandleFunc("GET /healthz", h.HealthCheck)
 }
 
+// Sizer reports the number of entries held by a component
+type Sizer interface {
+	Len() int
+}
+
+// InternalsHandler reports the size of the given components and the current
+// goroutine count, keyed by the names in sizers
+func InternalsHandler(sizers map[string]Sizer) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		status := map[string]interface{}{
+			"goroutines": runtime.NumGoroutine(),
+			"timestamp":  timestamp.Now(),
+		}
+		for name, sizer := range sizers {
+			status[name] = sizer.Len()
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(status)
+	}
+}
+
 // HealthCheck handles GET /healthz requests
 func (h *Handler) HealthCheck(w http.ResponseWriter, r *http.Request) {
 	status := map[string]interface{}{
 		"status":    "ok",
 		"uptime":    time.Since(h.startTime).String(),
-		"timestamp": time.Now().Format(time.RFC3339),
+		"timestamp": timestamp.Now(),
 	}
 
 	w.Header().Set("Content-Type", "application/json")
@@ -0,0 +1,46 @@
+package jobs
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/joshbarros/golang-carflow-api/internal/apierror"
+	"github.com/joshbarros/golang-carflow-api/internal/tenant"
+)
+
+// Handler serves job progress over HTTP
+type Handler struct {
+	store *Store
+}
+
+// NewHandler creates a new jobs handler
+func NewHandler(store *Store) *Handler {
+	return &Handler{
+		store: store,
+	}
+}
+
+// Mux is the part of *http.ServeMux that RegisterRoutes uses
+type Mux interface {
+	HandleFunc(pattern string, handler func(http.ResponseWriter, *http.Request))
+}
+
+// RegisterRoutes registers the job endpoints on mux
+func (h *Handler) RegisterRoutes(mux Mux) {
+	mux.HandleFunc("GET /jobs/{id}", h.handleGetJob)
+}
+
+// handleGetJob handles GET /jobs/{id} requests. Jobs belonging to other
+// tenants are reported as not found.
+func (h *Handler) handleGetJob(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	job, ok := h.store.Get(tenant.FromContext(r.Context()), r.PathValue("id"))
+	if !ok {
+		apierror.Write(w, http.StatusNotFound, apierror.JobNotFound, "Job not found")
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(job)
+}
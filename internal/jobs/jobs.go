@@ -0,0 +1,149 @@
+// Package jobs tracks the progress of long-running background operations
+// such as asynchronous batch imports.
+package jobs
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"sync"
+	"time"
+
+	"github.com/joshbarros/golang-carflow-api/internal/timestamp"
+)
+
+// Job statuses
+const (
+	StatusRunning   = "running"
+	StatusCompleted = "completed"
+)
+
+// DefaultRetention is how long finished jobs remain available for polling
+const DefaultRetention = time.Hour
+
+// Job reports the progress of a background operation
+type Job struct {
+	ID         string          `json:"id"`
+	Status     string          `json:"status"`
+	Total      int             `json:"total"`
+	Processed  int             `json:"processed"`
+	Errors     int             `json:"errors"`
+	Result     interface{}     `json:"result,omitempty"`
+	CreatedAt  timestamp.Time  `json:"created_at"`
+	FinishedAt *timestamp.Time `json:"finished_at,omitempty"`
+}
+
+// Store keeps jobs in memory, scoped per tenant. Finished jobs are dropped
+// once they are older than the retention period.
+type Store struct {
+	mu        sync.RWMutex
+	jobs      map[string]map[string]*Job
+	retention time.Duration
+}
+
+// NewStore creates a job store that keeps finished jobs for retention
+func NewStore(retention time.Duration) *Store {
+	return &Store{
+		jobs:      make(map[string]map[string]*Job),
+		retention: retention,
+	}
+}
+
+// Create registers a new running job for the tenant with the given number
+// of items to process
+func (s *Store) Create(tenantID string, total int) (Job, error) {
+	id, err := newID()
+	if err != nil {
+		return Job{}, err
+	}
+
+	job := &Job{
+		ID:        id,
+		Status:    StatusRunning,
+		Total:     total,
+		CreatedAt: timestamp.Now(),
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.pruneLocked(time.Now())
+	if s.jobs[tenantID] == nil {
+		s.jobs[tenantID] = make(map[string]*Job)
+	}
+	s.jobs[tenantID][id] = job
+
+	return *job, nil
+}
+
+// Progress records how many items a running job has processed and how many
+// of them failed
+func (s *Store) Progress(tenantID, id string, processed, errors int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if job, ok := s.jobs[tenantID][id]; ok {
+		job.Processed = processed
+		job.Errors = errors
+	}
+}
+
+// Complete marks a job as finished and attaches its result
+func (s *Store) Complete(tenantID, id string, result interface{}) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if job, ok := s.jobs[tenantID][id]; ok {
+		now := timestamp.Now()
+		job.Status = StatusCompleted
+		job.Result = result
+		job.FinishedAt = &now
+	}
+}
+
+// Get returns a snapshot of a tenant's job
+func (s *Store) Get(tenantID, id string) (Job, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	job, ok := s.jobs[tenantID][id]
+	if !ok {
+		return Job{}, false
+	}
+	return *job, true
+}
+
+// Len returns the number of jobs currently held
+func (s *Store) Len() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	n := 0
+	for _, tenantJobs := range s.jobs {
+		n += len(tenantJobs)
+	}
+	return n
+}
+
+// pruneLocked drops finished jobs older than the retention period. The
+// caller must hold the write lock.
+func (s *Store) pruneLocked(now time.Time) {
+	for tenantID, tenantJobs := range s.jobs {
+		for id, job := range tenantJobs {
+			if job.FinishedAt != nil && now.Sub(job.FinishedAt.Time) > s.retention {
+				delete(tenantJobs, id)
+			}
+		}
+		if len(tenantJobs) == 0 {
+			delete(s.jobs, tenantID)
+		}
+	}
+}
+
+// newID generates a random job ID
+func newID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,92 @@
+package jobs
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/joshbarros/golang-carflow-api/internal/tenant"
+)
+
+func TestStore_Lifecycle(t *testing.T) {
+	store := NewStore(DefaultRetention)
+
+	job, err := store.Create("acme", 3)
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	if job.Status != StatusRunning || job.Total != 3 {
+		t.Errorf("Create() = %+v, want a running job with 3 items", job)
+	}
+
+	store.Progress("acme", job.ID, 2, 1)
+	got, ok := store.Get("acme", job.ID)
+	if !ok || got.Processed != 2 || got.Errors != 1 {
+		t.Errorf("Get() after Progress = %+v, %v, want 2 processed and 1 error", got, ok)
+	}
+
+	store.Complete("acme", job.ID, "done")
+	got, _ = store.Get("acme", job.ID)
+	if got.Status != StatusCompleted || got.Result != "done" || got.FinishedAt == nil {
+		t.Errorf("Get() after Complete = %+v, want a completed job with its result", got)
+	}
+
+	// Jobs are invisible to other tenants
+	if _, ok := store.Get("globex", job.ID); ok {
+		t.Error("Get() from another tenant should not find the job")
+	}
+}
+
+func TestStore_PrunesFinishedJobs(t *testing.T) {
+	store := NewStore(time.Minute)
+
+	finished, _ := store.Create("acme", 1)
+	store.Complete("acme", finished.ID, nil)
+	running, _ := store.Create("acme", 1)
+
+	store.mu.Lock()
+	store.pruneLocked(time.Now().Add(2 * time.Minute))
+	store.mu.Unlock()
+
+	if _, ok := store.Get("acme", finished.ID); ok {
+		t.Error("finished job older than the retention period should be pruned")
+	}
+	if _, ok := store.Get("acme", running.ID); !ok {
+		t.Error("running job should never be pruned")
+	}
+	if store.Len() != 1 {
+		t.Errorf("Len() = %d, want 1", store.Len())
+	}
+}
+
+func TestHandler_GetJob(t *testing.T) {
+	store := NewStore(DefaultRetention)
+	job, _ := store.Create(tenant.DefaultID, 5)
+
+	mux := http.NewServeMux()
+	NewHandler(store).RegisterRoutes(mux)
+
+	tests := []struct {
+		name     string
+		path     string
+		tenantID string
+		want     int
+	}{
+		{"own job", "/jobs/" + job.ID, tenant.DefaultID, http.StatusOK},
+		{"unknown job", "/jobs/missing", tenant.DefaultID, http.StatusNotFound},
+		{"other tenant", "/jobs/" + job.ID, "globex", http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			req.Header.Set(tenant.Header, tt.tenantID)
+			rec := httptest.NewRecorder()
+			tenant.Middleware(mux).ServeHTTP(rec, req)
+			if rec.Code != tt.want {
+				t.Errorf("status = %d, want %d", rec.Code, tt.want)
+			}
+		})
+	}
+}
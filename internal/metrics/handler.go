@@ -4,6 +4,8 @@ import (
 	"encoding/json"
 	"net/http"
 	"time"
+
+	"github.com/joshbarros/golang-carflow-api/internal/timestamp"
 )
 
 // Handler handles metrics requests
@@ -18,8 +20,13 @@ func NewHandler(metrics *Metrics) *Handler {
 	}
 }
 
+// Mux is anything routes can be registered on, such as *http.ServeMux
+type Mux interface {
+	HandleFunc(pattern string, handler func(http.ResponseWriter, *http.Request))
+}
+
 // RegisterRoutes registers the metrics routes
-func (h *Handler) RegisterRoutes(mux *http.ServeMux) {
+func (h *Handler) RegisterRoutes(mux Mux) {
 	mux.HandleFunc("GET /metrics", h.GetMetrics)
 }
 
@@ -64,7 +71,7 @@ func Middleware(metrics *Metrics) func(http.Handler) http.Handler {
 				Method:    r.Method,
 				Status:    mrw.statusCode,
 				Duration:  duration,
-				Timestamp: time.Now(),
+				Timestamp: timestamp.Now(),
 			})
 		})
 	}
@@ -89,3 +96,9 @@ func (mrw *metricsResponseWriter) WriteHeader(code int) {
 	mrw.statusCode = code
 	mrw.ResponseWriter.WriteHeader(code)
 }
+
+// Unwrap returns the underlying response writer so http.ResponseController
+// can reach it
+func (mrw *metricsResponseWriter) Unwrap() http.ResponseWriter {
+	return mrw.ResponseWriter
+}
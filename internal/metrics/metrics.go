@@ -2,49 +2,76 @@ package metrics
 
 import (
 	"sync"
+	"sync/atomic"
 	"time"
+
+	"github.com/joshbarros/golang-carflow-api/internal/cache"
+	"github.com/joshbarros/golang-carflow-api/internal/timestamp"
+)
+
+const (
+	// DefaultResponseTimeWindow is the default number of response times kept for statistics
+	DefaultResponseTimeWindow = 100
+	// DefaultLastRequestsWindow is the default number of recent requests kept for inspection
+	DefaultLastRequestsWindow = 10
 )
 
 // Metrics tracks application metrics
 type Metrics struct {
 	RequestCount  int64
 	ErrorCount    int64
-	ResponseTimes []time.Duration
-	LastRequests  []RequestInfo
 	StartTime     time.Time
+	responseTimes *ringBuffer[time.Duration]
+	lastRequests  *ringBuffer[RequestInfo]
+	cache         StatsProvider
 	mu            sync.RWMutex
 }
 
+// StatsProvider reports cache statistics
+type StatsProvider interface {
+	Stats() cache.Stats
+}
+
 // RequestInfo contains information about a request
 type RequestInfo struct {
 	Path      string
 	Method    string
 	Status    int
 	Duration  time.Duration
-	Timestamp time.Time
+	Timestamp timestamp.Time
 }
 
-// NewMetrics creates a new metrics instance
+// NewMetrics creates a new metrics instance with the default window sizes
 func NewMetrics() *Metrics {
+	return NewMetricsWithWindows(DefaultResponseTimeWindow, DefaultLastRequestsWindow)
+}
+
+// NewMetricsWithWindows creates a new metrics instance keeping the given
+// number of response times and recent requests
+func NewMetricsWithWindows(responseTimes, lastRequests int) *Metrics {
 	return &Metrics{
-		ResponseTimes: make([]time.Duration, 0, 100),
-		LastRequests:  make([]RequestInfo, 0, 10),
 		StartTime:     time.Now(),
+		responseTimes: newRingBuffer[time.Duration](responseTimes),
+		lastRequests:  newRingBuffer[RequestInfo](lastRequests),
 	}
 }
 
-// IncrementRequestCount increments the request counter
-func (m *Metrics) IncrementRequestCount() {
+// SetCache includes the cache's statistics in GetStats under "cache"
+func (m *Metrics) SetCache(c StatsProvider) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
-	m.RequestCount++
+
+	m.cache = c
+}
+
+// IncrementRequestCount increments the request counter
+func (m *Metrics) IncrementRequestCount() {
+	atomic.AddInt64(&m.RequestCount, 1)
 }
 
 // IncrementErrorCount increments the error counter
 func (m *Metrics) IncrementErrorCount() {
-	m.mu.Lock()
-	defer m.mu.Unlock()
-	m.ErrorCount++
+	atomic.AddInt64(&m.ErrorCount, 1)
 }
 
 // AddResponseTime adds a response time measurement
@@ -52,11 +79,8 @@ func (m *Metrics) AddResponseTime(duration time.Duration) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
-	// Keep only the last 100 response times for percentile calculations
-	if len(m.ResponseTimes) >= 100 {
-		m.ResponseTimes = m.ResponseTimes[1:]
-	}
-	m.ResponseTimes = append(m.ResponseTimes, duration)
+	// Only the most recent response times are kept for percentile calculations
+	m.responseTimes.add(duration)
 }
 
 // AddRequestInfo adds information about a request
@@ -64,11 +88,8 @@ func (m *Metrics) AddRequestInfo(info RequestInfo) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
-	// Keep only the last 10 requests
-	if len(m.LastRequests) >= 10 {
-		m.LastRequests = m.LastRequests[1:]
-	}
-	m.LastRequests = append(m.LastRequests, info)
+	// Only the most recent requests are kept
+	m.lastRequests.add(info)
 }
 
 // GetStats gets the current metrics
@@ -78,24 +99,26 @@ func (m *Metrics) GetStats() map[string]interface{} {
 
 	stats := map[string]interface{}{
 		"requests": map[string]interface{}{
-			"total":  m.RequestCount,
-			"errors": m.ErrorCount,
+			"total":  atomic.LoadInt64(&m.RequestCount),
+			"errors": atomic.LoadInt64(&m.ErrorCount),
 		},
 		"uptime":        time.Since(m.StartTime).String(),
-		"last_requests": m.LastRequests,
+		"last_requests": m.lastRequests.snapshot(),
 	}
 
 	// Calculate response time percentiles if we have enough data
-	if len(m.ResponseTimes) > 0 {
-		// Make a copy to avoid modifying the original
-		times := make([]time.Duration, len(m.ResponseTimes))
-		copy(times, m.ResponseTimes)
+	if m.responseTimes.len() > 0 {
+		// Snapshot returns a copy, so the buffer isn't modified
+		times := m.responseTimes.snapshot()
 
-		// Sort the times
 		timeStats := calculateTimeStats(times)
 		stats["response_times"] = timeStats
 	}
 
+	if m.cache != nil {
+		stats["cache"] = m.cache.Stats()
+	}
+
 	return stats
 }
 
@@ -0,0 +1,124 @@
+package metrics
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/joshbarros/golang-carflow-api/internal/cache"
+)
+
+func TestRingBuffer(t *testing.T) {
+	rb := newRingBuffer[int](3)
+
+	if got := rb.snapshot(); len(got) != 0 {
+		t.Errorf("snapshot() of empty buffer = %v, want empty", got)
+	}
+
+	for i := 1; i <= 5; i++ {
+		rb.add(i)
+	}
+
+	got := rb.snapshot()
+	want := []int{3, 4, 5}
+	if len(got) != len(want) {
+		t.Fatalf("snapshot() = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("snapshot() = %v, want %v", got, want)
+			break
+		}
+	}
+}
+
+func TestMetrics_Concurrent(t *testing.T) {
+	m := NewMetrics()
+
+	const workers = 50
+	const perWorker = 200
+
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < perWorker; j++ {
+				m.IncrementRequestCount()
+				m.AddResponseTime(time.Millisecond)
+				m.AddRequestInfo(RequestInfo{Path: "/cars", Method: "GET", Status: 200})
+				if j%10 == 0 {
+					m.GetStats()
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	stats := m.GetStats()
+	requests := stats["requests"].(map[string]interface{})
+	if total := requests["total"].(int64); total != workers*perWorker {
+		t.Errorf("total requests = %d, want %d", total, workers*perWorker)
+	}
+
+	if last := stats["last_requests"].([]RequestInfo); len(last) != DefaultLastRequestsWindow {
+		t.Errorf("last_requests has %d entries, want %d", len(last), DefaultLastRequestsWindow)
+	}
+
+	times := stats["response_times"].(map[string]interface{})
+	if count := times["count"].(int); count != DefaultResponseTimeWindow {
+		t.Errorf("response_times count = %d, want %d", count, DefaultResponseTimeWindow)
+	}
+}
+
+func BenchmarkMetrics_RecordRequest(b *testing.B) {
+	m := NewMetrics()
+	info := RequestInfo{Path: "/cars", Method: "GET", Status: 200}
+
+	b.ReportAllocs()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			m.IncrementRequestCount()
+			m.AddResponseTime(time.Millisecond)
+			m.AddRequestInfo(info)
+		}
+	})
+}
+
+func TestNewMetricsWithWindows(t *testing.T) {
+	m := NewMetricsWithWindows(5, 2)
+	for i := 0; i < 10; i++ {
+		m.AddResponseTime(time.Millisecond)
+		m.AddRequestInfo(RequestInfo{Path: "/cars"})
+	}
+
+	stats := m.GetStats()
+	if last := stats["last_requests"].([]RequestInfo); len(last) != 2 {
+		t.Errorf("last_requests has %d entries, want 2", len(last))
+	}
+	times := stats["response_times"].(map[string]interface{})
+	if count := times["count"].(int); count != 5 {
+		t.Errorf("response_times count = %d, want 5", count)
+	}
+}
+
+func TestMetrics_CacheStats(t *testing.T) {
+	m := NewMetrics()
+	if _, ok := m.GetStats()["cache"]; ok {
+		t.Errorf("GetStats() includes cache without one set")
+	}
+
+	c := cache.New(0, 0)
+	c.Set("key", "value", 0)
+	c.Get("key")
+	c.Get("missing")
+	m.SetCache(c)
+
+	stats, ok := m.GetStats()["cache"].(cache.Stats)
+	if !ok {
+		t.Fatalf("GetStats() cache = %v, want cache.Stats", m.GetStats()["cache"])
+	}
+	if stats.Hits != 1 || stats.Misses != 1 || stats.Items != 1 {
+		t.Errorf("GetStats() cache = %+v, want 1 hit, 1 miss and 1 item", stats)
+	}
+}
@@ -0,0 +1,47 @@
+package metrics
+
+// ringBuffer is a fixed-size circular buffer that overwrites its oldest
+// entry once full. It is not safe for concurrent use on its own.
+type ringBuffer[T any] struct {
+	items []T
+	next  int
+	full  bool
+}
+
+// newRingBuffer creates a ring buffer holding at most size items
+func newRingBuffer[T any](size int) *ringBuffer[T] {
+	return &ringBuffer[T]{
+		items: make([]T, size),
+	}
+}
+
+// add stores a value, replacing the oldest one when the buffer is full
+func (rb *ringBuffer[T]) add(value T) {
+	if len(rb.items) == 0 {
+		return
+	}
+
+	rb.items[rb.next] = value
+	rb.next++
+	if rb.next == len(rb.items) {
+		rb.next = 0
+		rb.full = true
+	}
+}
+
+// len returns the number of values currently stored
+func (rb *ringBuffer[T]) len() int {
+	if rb.full {
+		return len(rb.items)
+	}
+	return rb.next
+}
+
+// snapshot returns a copy of the stored values, oldest first
+func (rb *ringBuffer[T]) snapshot() []T {
+	result := make([]T, 0, rb.len())
+	if rb.full {
+		result = append(result, rb.items[rb.next:]...)
+	}
+	return append(result, rb.items[:rb.next]...)
+}
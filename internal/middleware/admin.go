@@ -0,0 +1,41 @@
+package middleware
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strings"
+
+	"github.com/joshbarros/golang-carflow-api/internal/apierror"
+)
+
+// RequireAdminToken restricts a handler to requests carrying the given token
+// as "Authorization: Bearer <token>". With an empty token the handler is
+// disabled and always answers 404.
+func RequireAdminToken(token string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if token == "" {
+				apierror.Write(w, http.StatusNotFound, apierror.NotFound, "Not found")
+				return
+			}
+
+			if !HasAdminToken(r, token) {
+				apierror.Write(w, http.StatusUnauthorized, apierror.Unauthorized, "Admin authorization required")
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// HasAdminToken reports whether the request carries the given non-empty
+// token as "Authorization: Bearer <token>"
+func HasAdminToken(r *http.Request, token string) bool {
+	if token == "" {
+		return false
+	}
+
+	provided, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	return ok && subtle.ConstantTimeCompare([]byte(provided), []byte(token)) == 1
+}
@@ -0,0 +1,64 @@
+package middleware
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/joshbarros/golang-carflow-api/internal/apierror"
+)
+
+const (
+	// ChaosDelayHeader asks for the response to be delayed by a duration
+	// such as "500ms" or "2s"
+	ChaosDelayHeader = "X-Chaos-Delay"
+	// ChaosErrorHeader asks for the request to fail with 503 with the given
+	// probability between 0 and 1
+	ChaosErrorHeader = "X-Chaos-Error"
+)
+
+// ChaosHeaders lists the request headers ChaosMiddleware reads, for adding
+// to the CORS allowed headers when chaos testing is enabled
+var ChaosHeaders = []string{ChaosDelayHeader, ChaosErrorHeader}
+
+// ChaosMiddleware injects latency and failures on request, for testing how
+// clients handle timeouts and retries. Delays longer than maxDelay are
+// rejected. It must never be installed in production; the configuration
+// refuses to enable it there.
+func ChaosMiddleware(maxDelay time.Duration) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if value := r.Header.Get(ChaosDelayHeader); value != "" {
+				delay, err := time.ParseDuration(value)
+				if err != nil || delay < 0 || delay > maxDelay {
+					apierror.Write(w, http.StatusBadRequest, apierror.BadRequest, fmt.Sprintf("%s must be a duration between 0 and %s", ChaosDelayHeader, maxDelay))
+					return
+				}
+
+				timer := time.NewTimer(delay)
+				select {
+				case <-timer.C:
+				case <-r.Context().Done():
+					timer.Stop()
+					return
+				}
+			}
+
+			if value := r.Header.Get(ChaosErrorHeader); value != "" {
+				probability, err := strconv.ParseFloat(value, 64)
+				if err != nil || probability < 0 || probability > 1 {
+					apierror.Write(w, http.StatusBadRequest, apierror.BadRequest, fmt.Sprintf("%s must be a probability between 0 and 1", ChaosErrorHeader))
+					return
+				}
+				if rand.Float64() < probability {
+					apierror.Write(w, http.StatusServiceUnavailable, apierror.ServiceUnavailable, "Injected failure")
+					return
+				}
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,50 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestChaosMiddleware(t *testing.T) {
+	handler := ChaosMiddleware(100 * time.Millisecond)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	tests := []struct {
+		name      string
+		headers   map[string]string
+		want      int
+		wantDelay time.Duration
+	}{
+		{name: "No chaos", want: http.StatusOK},
+		{name: "Delay", headers: map[string]string{ChaosDelayHeader: "50ms"}, want: http.StatusOK, wantDelay: 50 * time.Millisecond},
+		{name: "Delay too long", headers: map[string]string{ChaosDelayHeader: "1s"}, want: http.StatusBadRequest},
+		{name: "Invalid delay", headers: map[string]string{ChaosDelayHeader: "soon"}, want: http.StatusBadRequest},
+		{name: "Always fail", headers: map[string]string{ChaosErrorHeader: "1"}, want: http.StatusServiceUnavailable},
+		{name: "Never fail", headers: map[string]string{ChaosErrorHeader: "0"}, want: http.StatusOK},
+		{name: "Invalid probability", headers: map[string]string{ChaosErrorHeader: "2"}, want: http.StatusBadRequest},
+		{name: "Delay then fail", headers: map[string]string{ChaosDelayHeader: "10ms", ChaosErrorHeader: "1"}, want: http.StatusServiceUnavailable, wantDelay: 10 * time.Millisecond},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/cars", nil)
+			for k, v := range tt.headers {
+				req.Header.Set(k, v)
+			}
+
+			start := time.Now()
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			if rec.Code != tt.want {
+				t.Errorf("status = %d, want %d", rec.Code, tt.want)
+			}
+			if elapsed := time.Since(start); elapsed < tt.wantDelay {
+				t.Errorf("elapsed = %s, want at least %s", elapsed, tt.wantDelay)
+			}
+		})
+	}
+}
@@ -2,23 +2,46 @@ package middleware
 
 import (
 	"net/http"
+	"slices"
+	"strings"
 )
 
-// CORSMiddleware adds CORS headers to allow cross-origin requests
+// CORSMiddleware adds CORS headers to allow cross-origin requests from any origin
 func CORSMiddleware(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		// Set CORS headers
-		w.Header().Set("Access-Control-Allow-Origin", "*")
-		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+	return CORSWithOrigins(nil)(next)
+}
+
+// CORSWithOrigins creates a CORS middleware restricted to the given origins.
+// An empty list or "*" allows any origin without credentials; otherwise only
+// listed origins are reflected and allowed to send credentials. extraHeaders
+// are allowed in requests on top of Content-Type and Authorization.
+func CORSWithOrigins(allowedOrigins []string, extraHeaders ...string) func(http.Handler) http.Handler {
+	allowAny := len(allowedOrigins) == 0 || slices.Contains(allowedOrigins, "*")
+	allowedHeaders := strings.Join(append([]string{"Content-Type", "Authorization"}, extraHeaders...), ", ")
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			// Set CORS headers
+			if allowAny {
+				w.Header().Set("Access-Control-Allow-Origin", "*")
+			} else {
+				w.Header().Add("Vary", "Origin")
+				if origin := r.Header.Get("Origin"); origin != "" && slices.Contains(allowedOrigins, origin) {
+					w.Header().Set("Access-Control-Allow-Origin", origin)
+					w.Header().Set("Access-Control-Allow-Credentials", "true")
+				}
+			}
+			w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, PATCH, DELETE, OPTIONS")
+			w.Header().Set("Access-Control-Allow-Headers", allowedHeaders)
 
-		// Handle preflight requests
-		if r.Method == http.MethodOptions {
-			w.WriteHeader(http.StatusNoContent)
-			return
-		}
+			// Handle preflight requests
+			if r.Method == http.MethodOptions {
+				w.WriteHeader(http.StatusNoContent)
+				return
+			}
 
-		// Call the next handler
-		next.ServeHTTP(w, r)
-	})
+			// Call the next handler
+			next.ServeHTTP(w, r)
+		})
+	}
 }
@@ -0,0 +1,55 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestCORSWithOrigins(t *testing.T) {
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	tests := []struct {
+		name            string
+		allowed         []string
+		origin          string
+		wantOrigin      string
+		wantCredentials string
+	}{
+		{name: "Wildcard", allowed: nil, origin: "https://evil.example", wantOrigin: "*"},
+		{name: "Allowed origin", allowed: []string{"https://ui.example"}, origin: "https://ui.example", wantOrigin: "https://ui.example", wantCredentials: "true"},
+		{name: "Other origin", allowed: []string{"https://ui.example"}, origin: "https://evil.example"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/cars", nil)
+			req.Header.Set("Origin", tt.origin)
+			rec := httptest.NewRecorder()
+
+			CORSWithOrigins(tt.allowed)(next).ServeHTTP(rec, req)
+
+			if got := rec.Header().Get("Access-Control-Allow-Origin"); got != tt.wantOrigin {
+				t.Errorf("Access-Control-Allow-Origin = %q, want %q", got, tt.wantOrigin)
+			}
+			if got := rec.Header().Get("Access-Control-Allow-Credentials"); got != tt.wantCredentials {
+				t.Errorf("Access-Control-Allow-Credentials = %q, want %q", got, tt.wantCredentials)
+			}
+		})
+	}
+}
+
+func TestCORSWithOrigins_ExtraHeaders(t *testing.T) {
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+	req := httptest.NewRequest(http.MethodOptions, "/cars", nil)
+	rec := httptest.NewRecorder()
+
+	CORSWithOrigins(nil, ChaosHeaders...)(next).ServeHTTP(rec, req)
+
+	want := "Content-Type, Authorization, X-Chaos-Delay, X-Chaos-Error"
+	if got := rec.Header().Get("Access-Control-Allow-Headers"); got != want {
+		t.Errorf("Access-Control-Allow-Headers = %q, want %q", got, want)
+	}
+}
@@ -5,13 +5,18 @@ import (
 	"crypto/md5"
 	"encoding/hex"
 	"net/http"
+	"strings"
 )
 
-// ETagWriter is a custom response writer that captures the response for ETag generation
+// ETagWriter is a custom response writer that captures the response for ETag generation.
+// Responses marked Cache-Control: no-store are not cacheable, so they are
+// passed straight through instead of being buffered, which lets handlers
+// stream them.
 type ETagWriter struct {
 	http.ResponseWriter
-	buf    *bytes.Buffer
-	status int
+	buf       *bytes.Buffer
+	status    int
+	streaming bool
 }
 
 // NewETagWriter creates a new ETag writer
@@ -25,15 +30,52 @@ func NewETagWriter(w http.ResponseWriter) *ETagWriter {
 
 // WriteHeader captures the status code
 func (e *ETagWriter) WriteHeader(code int) {
+	if e.streaming || e.startStreaming() {
+		e.ResponseWriter.WriteHeader(code)
+		return
+	}
 	e.status = code
 	// Don't write header yet, it will be written when we flush
 }
 
 // Write captures the response body
 func (e *ETagWriter) Write(b []byte) (int, error) {
+	if e.streaming || (e.buf.Len() == 0 && e.startStreaming()) {
+		return e.ResponseWriter.Write(b)
+	}
 	return e.buf.Write(b)
 }
 
+// FlushError sends buffered data to the client when the response is being
+// streamed. Buffered responses are left alone until the handler returns.
+// It lets handlers flush through http.ResponseController.
+func (e *ETagWriter) FlushError() error {
+	if !e.streaming {
+		return nil
+	}
+	return http.NewResponseController(e.ResponseWriter).Flush()
+}
+
+// Unwrap returns the underlying response writer
+func (e *ETagWriter) Unwrap() http.ResponseWriter {
+	return e.ResponseWriter
+}
+
+// startStreaming switches to pass-through mode when the handler marked the
+// response as not cacheable. It is only consulted before anything has been
+// buffered.
+func (e *ETagWriter) startStreaming() bool {
+	if !strings.Contains(e.ResponseWriter.Header().Get("Cache-Control"), "no-store") {
+		return false
+	}
+	if e.status != http.StatusOK {
+		// The handler already set a status while we were buffering
+		return false
+	}
+	e.streaming = true
+	return true
+}
+
 // generateETag generates an ETag from the response body
 func (e *ETagWriter) generateETag() string {
 	hash := md5.Sum(e.buf.Bytes())
@@ -42,6 +84,10 @@ func (e *ETagWriter) generateETag() string {
 
 // Flush writes the actual response with ETag header
 func (e *ETagWriter) Flush(r *http.Request) {
+	if e.streaming {
+		return
+	}
+
 	// Only add ETag for successful GET responses
 	if e.status == http.StatusOK {
 		etag := e.generateETag()
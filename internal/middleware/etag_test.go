@@ -0,0 +1,47 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestETagMiddleware(t *testing.T) {
+	tests := []struct {
+		name         string
+		cacheControl string
+		wantETag     bool
+		wantFlushed  bool
+	}{
+		{"buffered", "", true, false},
+		{"no-store streams", "no-store", false, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var flushedMidway bool
+			handler := ETagMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if tt.cacheControl != "" {
+					w.Header().Set("Cache-Control", tt.cacheControl)
+				}
+				w.Write([]byte("first"))
+				http.NewResponseController(w).Flush()
+				flushedMidway = w.(*ETagWriter).ResponseWriter.(*httptest.ResponseRecorder).Flushed
+				w.Write([]byte("second"))
+			}))
+
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+
+			if got := rec.Body.String(); got != "firstsecond" {
+				t.Errorf("body = %q, want %q", got, "firstsecond")
+			}
+			if got := rec.Header().Get("ETag") != ""; got != tt.wantETag {
+				t.Errorf("ETag present = %v, want %v", got, tt.wantETag)
+			}
+			if flushedMidway != tt.wantFlushed {
+				t.Errorf("flushed before handler returned = %v, want %v", flushedMidway, tt.wantFlushed)
+			}
+		})
+	}
+}
@@ -0,0 +1,51 @@
+package middleware
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/joshbarros/golang-carflow-api/internal/apierror"
+)
+
+// PlainErrorsMiddleware turns plain-text error responses, such as the 404
+// and 405 that ServeMux writes for unmatched routes, into JSON error bodies
+// with a code. JSON responses pass through unchanged.
+func PlainErrorsMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		next.ServeHTTP(&plainErrorWriter{ResponseWriter: w}, r)
+	})
+}
+
+// plainErrorWriter replaces a plain-text error response with apierror's
+// JSON body and drops the plain-text body written after it
+type plainErrorWriter struct {
+	http.ResponseWriter
+	replaced bool
+}
+
+// WriteHeader writes a JSON error body instead of a plain-text error
+func (pw *plainErrorWriter) WriteHeader(code int) {
+	contentType := pw.Header().Get("Content-Type")
+	if code < http.StatusBadRequest || !strings.HasPrefix(contentType, "text/plain") {
+		pw.ResponseWriter.WriteHeader(code)
+		return
+	}
+
+	pw.replaced = true
+	pw.Header().Del("X-Content-Type-Options")
+	apierror.Write(pw.ResponseWriter, code, "", http.StatusText(code))
+}
+
+// Write discards the plain-text body of a replaced error
+func (pw *plainErrorWriter) Write(b []byte) (int, error) {
+	if pw.replaced {
+		return len(b), nil
+	}
+	return pw.ResponseWriter.Write(b)
+}
+
+// Unwrap returns the underlying response writer so http.ResponseController
+// can reach it
+func (pw *plainErrorWriter) Unwrap() http.ResponseWriter {
+	return pw.ResponseWriter
+}
@@ -0,0 +1,51 @@
+package middleware
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/joshbarros/golang-carflow-api/internal/apierror"
+)
+
+func TestPlainErrorsMiddleware(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /cars", func(w http.ResponseWriter, r *http.Request) {
+		apierror.Write(w, http.StatusBadRequest, apierror.ValidationFailed, "make is required")
+	})
+	handler := PlainErrorsMiddleware(mux)
+
+	tests := []struct {
+		name     string
+		method   string
+		path     string
+		wantCode int
+		wantBody apierror.Body
+	}{
+		{"unmatched route", http.MethodGet, "/missing", http.StatusNotFound, apierror.Body{Error: "Not Found", Code: apierror.NotFound}},
+		{"wrong method", http.MethodDelete, "/cars", http.StatusMethodNotAllowed, apierror.Body{Error: "Method Not Allowed", Code: apierror.MethodNotAllowed}},
+		{"JSON error passes through", http.MethodGet, "/cars", http.StatusBadRequest, apierror.Body{Error: "make is required", Code: apierror.ValidationFailed}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, httptest.NewRequest(tt.method, tt.path, nil))
+
+			if rec.Code != tt.wantCode {
+				t.Errorf("status = %d, want %d", rec.Code, tt.wantCode)
+			}
+			if ct := rec.Header().Get("Content-Type"); ct != "application/json" {
+				t.Errorf("Content-Type = %q, want application/json", ct)
+			}
+			var body apierror.Body
+			if err := json.NewDecoder(rec.Body).Decode(&body); err != nil {
+				t.Fatalf("decoding body: %v", err)
+			}
+			if body != tt.wantBody {
+				t.Errorf("body = %+v, want %+v", body, tt.wantBody)
+			}
+		})
+	}
+}
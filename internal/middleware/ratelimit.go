@@ -1,20 +1,37 @@
 package middleware
 
 import (
+	"encoding/json"
+	"fmt"
+	"math"
 	"net"
 	"net/http"
 	"strconv"
 	"sync"
 	"time"
+
+	"github.com/joshbarros/golang-carflow-api/internal/apierror"
+	"github.com/joshbarros/golang-carflow-api/internal/timestamp"
+)
+
+// Rate limit units
+const (
+	UnitSecond = "second"
+	UnitMinute = "minute"
 )
 
+// RateLimitUnits lists the units a rate can be given in
+var RateLimitUnits = []string{UnitSecond, UnitMinute}
+
 // RateLimiter implements a simple token bucket rate limiter
 type RateLimiter struct {
-	clients    map[string]*client
-	rate       int // requests per second
-	burst      int // maximum burst size
-	mu         sync.Mutex
-	cleanupInt time.Duration // cleanup interval
+	clients       map[string]*client
+	rate          int     // requests per unit
+	unit          string  // UnitSecond or UnitMinute
+	burst         int     // maximum burst size
+	softThreshold float64 // fraction of the burst after which clients are warned
+	mu            sync.Mutex
+	cleanupInt    time.Duration // cleanup interval
 }
 
 // client tracks rate limiting state for a single client
@@ -28,6 +45,7 @@ func NewRateLimiter(rate, burst int, cleanupInterval time.Duration) *RateLimiter
 	limiter := &RateLimiter{
 		clients:    make(map[string]*client),
 		rate:       rate,
+		unit:       UnitSecond,
 		burst:      burst,
 		cleanupInt: cleanupInterval,
 	}
@@ -38,8 +56,56 @@ func NewRateLimiter(rate, burst int, cleanupInterval time.Duration) *RateLimiter
 	return limiter
 }
 
+// SetSoftThreshold sets the fraction of the burst (0-1) a client may use
+// before responses carry a warning header. Zero disables warnings.
+func (rl *RateLimiter) SetSoftThreshold(threshold float64) {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+	rl.softThreshold = threshold
+}
+
+// SetUnit sets the unit the rate is given in, one of RateLimitUnits. The
+// default is UnitSecond.
+func (rl *RateLimiter) SetUnit(unit string) {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+	rl.unit = unit
+}
+
+// refilled returns how many whole tokens the rate adds over elapsed
+func (rl *RateLimiter) refilled(elapsed time.Duration) int {
+	return int(elapsed.Seconds() / rl.unitDuration().Seconds() * float64(rl.rate))
+}
+
+// tokenInterval returns how long the rate takes to add one token
+func (rl *RateLimiter) tokenInterval() time.Duration {
+	return rl.unitDuration() / time.Duration(max(rl.rate, 1))
+}
+
+// limit returns the rate and its unit
+func (rl *RateLimiter) limit() (int, string) {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+	return rl.rate, rl.unit
+}
+
+// unitDuration returns the length of the rate's unit
+func (rl *RateLimiter) unitDuration() time.Duration {
+	if rl.unit == UnitMinute {
+		return time.Minute
+	}
+	return time.Second
+}
+
 // Allow returns true if the client is allowed to make a request
 func (rl *RateLimiter) Allow(clientIP string) bool {
+	allowed, _ := rl.allow(clientIP)
+	return allowed
+}
+
+// allow consumes a token for the client and reports whether the request is
+// allowed and whether the client has crossed the soft threshold
+func (rl *RateLimiter) allow(clientIP string) (allowed bool, warn bool) {
 	rl.mu.Lock()
 	defer rl.mu.Unlock()
 
@@ -52,17 +118,17 @@ func (rl *RateLimiter) Allow(clientIP string) bool {
 		}
 		rl.clients[clientIP] = c
 	} else {
-		// Add tokens based on time elapsed
+		// Add the whole tokens earned since the last refill. lastUpdate only
+		// moves forward by the time those tokens account for, so time
+		// toward the next token isn't lost between frequent requests.
 		now := time.Now()
-		elapsed := now.Sub(c.lastUpdate)
-		c.lastUpdate = now
-
-		// Calculate tokens to add based on elapsed time and rate
-		newTokens := int(elapsed.Seconds() * float64(rl.rate))
+		newTokens := rl.refilled(now.Sub(c.lastUpdate))
 		if newTokens > 0 {
 			c.tokens += newTokens
-			if c.tokens > rl.burst {
+			c.lastUpdate = c.lastUpdate.Add(time.Duration(newTokens) * rl.tokenInterval())
+			if c.tokens >= rl.burst {
 				c.tokens = rl.burst
+				c.lastUpdate = now
 			}
 		}
 	}
@@ -70,10 +136,20 @@ func (rl *RateLimiter) Allow(clientIP string) bool {
 	// Check if client has tokens
 	if c.tokens > 0 {
 		c.tokens--
-		return true
+		used := rl.burst - c.tokens
+		warn = rl.softThreshold > 0 && float64(used) >= rl.softThreshold*float64(rl.burst)
+		return true, warn
 	}
 
-	return false
+	return false, false
+}
+
+// Len returns the number of clients currently tracked by the limiter
+func (rl *RateLimiter) Len() int {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+
+	return len(rl.clients)
 }
 
 // TimeUntilRefill returns seconds until the next token is available
@@ -86,16 +162,70 @@ func (rl *RateLimiter) TimeUntilRefill(clientIP string) int {
 		return 0 // No wait needed
 	}
 
-	// Calculate time needed for at least one token
-	secondsNeeded := 1
-	if rl.rate > 0 {
-		secondsNeeded = 1 / rl.rate
-		if secondsNeeded < 1 {
-			secondsNeeded = 1
+	// Time left until the next token, counting the time already earned
+	// toward it
+	wait := rl.tokenInterval() - time.Since(c.lastUpdate)
+	return max(int(math.Ceil(wait.Seconds())), 1)
+}
+
+// RateLimitStatus describes a client's current rate-limit state. Limit is
+// the number of requests allowed per Unit.
+type RateLimitStatus struct {
+	Limit     int            `json:"limit"`
+	Unit      string         `json:"unit"`
+	Burst     int            `json:"burst"`
+	Remaining int            `json:"remaining"`
+	Reset     timestamp.Time `json:"reset"`
+}
+
+// Status returns the client's rate-limit state without consuming a token
+func (rl *RateLimiter) Status(clientIP string) RateLimitStatus {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+
+	now := time.Now()
+	remaining := rl.burst
+	if c, exists := rl.clients[clientIP]; exists {
+		// Apply the same refill as Allow without updating the client
+		remaining = c.tokens + rl.refilled(now.Sub(c.lastUpdate))
+		if remaining > rl.burst {
+			remaining = rl.burst
 		}
 	}
 
-	return secondsNeeded
+	// Time until the bucket is full again
+	reset := now
+	if rl.rate > 0 && remaining < rl.burst {
+		reset = now.Add(time.Duration(float64(rl.burst-remaining) / float64(rl.rate) * float64(rl.unitDuration())))
+	}
+
+	return RateLimitStatus{
+		Limit:     rl.rate,
+		Unit:      rl.unit,
+		Burst:     rl.burst,
+		Remaining: remaining,
+		Reset:     timestamp.New(reset),
+	}
+}
+
+// RateLimitStatusHandler serves the calling client's rate-limit state
+func RateLimitStatusHandler(limiter *RateLimiter) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		status := limiter.Status(clientIP(r))
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(status)
+	}
+}
+
+// clientIP returns the IP address of the client making the request
+func clientIP(r *http.Request) string {
+	ip, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr // Fallback if SplitHostPort fails
+	}
+	return ip
 }
 
 // cleanup removes clients that haven't been seen in a while
@@ -117,29 +247,48 @@ func (rl *RateLimiter) cleanup(interval time.Duration) {
 	}
 }
 
+// rateLimitError is the JSON body returned when a client is rate limited.
+// It predates apierror.Body and keeps its error value for compatibility.
+type rateLimitError struct {
+	Error      string        `json:"error"`
+	Code       apierror.Code `json:"code"`
+	Message    string        `json:"message"`
+	RetryAfter int           `json:"retry_after"`
+}
+
 // RateLimitMiddleware creates a middleware that limits requests based on client IP
 func RateLimitMiddleware(limiter *RateLimiter) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			// Get client IP
-			ip, _, err := net.SplitHostPort(r.RemoteAddr)
-			if err != nil {
-				ip = r.RemoteAddr // Fallback if SplitHostPort fails
-			}
+			ip := clientIP(r)
 
 			// Check if client is allowed
-			if !limiter.Allow(ip) {
+			allowed, warn := limiter.allow(ip)
+			if !allowed {
 				// Calculate retry time
 				retryAfter := limiter.TimeUntilRefill(ip)
+				rate, unit := limiter.limit()
 
 				// Set headers
 				w.Header().Set("Content-Type", "application/json")
 				w.Header().Set("Retry-After", strconv.Itoa(retryAfter))
 				w.WriteHeader(http.StatusTooManyRequests)
-				w.Write([]byte(`{"error":"Rate limit exceeded. Try again later."}`))
+				json.NewEncoder(w).Encode(rateLimitError{
+					Error:      "rate_limit_exceeded",
+					Code:       apierror.RateLimited,
+					Message:    fmt.Sprintf("Rate limit of %d requests per %s exceeded. Try again later.", rate, unit),
+					RetryAfter: retryAfter,
+				})
 				return
 			}
 
+			// Warn clients approaching the limit so they can back off
+			if warn {
+				rate, unit := limiter.limit()
+				w.Header().Set("X-RateLimit-Warning", fmt.Sprintf("Approaching rate limit of %d requests per %s", rate, unit))
+			}
+
 			next.ServeHTTP(w, r)
 		})
 	}
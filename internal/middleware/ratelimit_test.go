@@ -0,0 +1,138 @@
+package middleware
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRateLimitMiddleware_ExceededReturnsJSON(t *testing.T) {
+	limiter := NewRateLimiter(1, 1, time.Minute)
+	limiter.SetUnit(UnitMinute)
+	handler := RateLimitMiddleware(limiter)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/cars", nil)
+	req.RemoteAddr = "192.0.2.1:1234"
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("first request status = %d, want %d", rec.Code, http.StatusOK)
+	}
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if rec.Code != http.StatusTooManyRequests {
+		t.Fatalf("second request status = %d, want %d", rec.Code, http.StatusTooManyRequests)
+	}
+
+	if ct := rec.Header().Get("Content-Type"); ct != "application/json" {
+		t.Errorf("Content-Type = %q, want application/json", ct)
+	}
+	if got := rec.Header().Get("Retry-After"); got != "60" {
+		t.Errorf("Retry-After = %q, want 60 for one request per minute", got)
+	}
+
+	var body map[string]interface{}
+	if err := json.NewDecoder(rec.Body).Decode(&body); err != nil {
+		t.Fatalf("failed to decode body: %v", err)
+	}
+	if body["error"] != "rate_limit_exceeded" || body["code"] != "rate_limited" {
+		t.Errorf("error = %v, code = %v, want rate_limit_exceeded and rate_limited", body["error"], body["code"])
+	}
+	if _, ok := body["retry_after"].(float64); !ok {
+		t.Errorf("retry_after = %v, want a number", body["retry_after"])
+	}
+	if msg, _ := body["message"].(string); !strings.Contains(msg, "1 requests per minute") {
+		t.Errorf("message = %v, want it to state the per-minute limit", body["message"])
+	}
+}
+
+func TestRateLimitMiddleware_SoftThresholdWarning(t *testing.T) {
+	limiter := NewRateLimiter(1, 10, time.Minute)
+	limiter.SetSoftThreshold(0.8)
+	handler := RateLimitMiddleware(limiter)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/cars", nil)
+	req.RemoteAddr = "192.0.2.2:1234"
+
+	for i := 1; i <= 10; i++ {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+
+		if rec.Code != http.StatusOK {
+			t.Fatalf("request %d status = %d, want %d", i, rec.Code, http.StatusOK)
+		}
+
+		// The warning starts once 8 of the 10 burst tokens are used
+		warning := rec.Header().Get("X-RateLimit-Warning")
+		if i < 8 && warning != "" {
+			t.Errorf("request %d unexpectedly carried warning %q", i, warning)
+		}
+		if i >= 8 && warning == "" {
+			t.Errorf("request %d expected X-RateLimit-Warning header", i)
+		}
+	}
+}
+
+func TestRateLimiter_StatusMatchesMiddleware(t *testing.T) {
+	limiter := NewRateLimiter(1, 5, time.Minute)
+	handler := RateLimitMiddleware(limiter)(RateLimitStatusHandler(limiter))
+
+	req := httptest.NewRequest(http.MethodGet, "/me/rate-limit", nil)
+	req.RemoteAddr = "192.0.2.3:1234"
+
+	var status RateLimitStatus
+	for i := 0; i < 2; i++ {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("status code = %d, want %d", rec.Code, http.StatusOK)
+		}
+		if err := json.NewDecoder(rec.Body).Decode(&status); err != nil {
+			t.Fatalf("failed to decode body: %v", err)
+		}
+	}
+
+	// Two requests went through the middleware, each consuming a token
+	if status.Remaining != 3 {
+		t.Errorf("remaining = %d, want 3", status.Remaining)
+	}
+	if status.Limit != 1 || status.Unit != UnitSecond || status.Burst != 5 {
+		t.Errorf("status = %+v, want limit 1 per second and burst 5", status)
+	}
+	if !status.Reset.After(time.Now()) {
+		t.Errorf("reset = %v, want a time in the future", status.Reset)
+	}
+}
+
+func TestRateLimiter_RefillsBetweenFrequentRequestsPerMinute(t *testing.T) {
+	// 600 per minute adds a token every 100ms; polling every 30ms must
+	// still earn tokens back instead of resetting the refill each time
+	limiter := NewRateLimiter(600, 1, time.Minute)
+	limiter.SetUnit(UnitMinute)
+
+	const ip = "192.0.2.4"
+	if !limiter.Allow(ip) {
+		t.Fatal("first request was rejected")
+	}
+
+	allowed := 0
+	for i := 0; i < 20; i++ {
+		time.Sleep(30 * time.Millisecond)
+		if limiter.Allow(ip) {
+			allowed++
+		}
+	}
+
+	if allowed < 2 {
+		t.Errorf("allowed %d of 20 requests over 600ms, want tokens to keep refilling", allowed)
+	}
+}
@@ -4,6 +4,8 @@ import (
 	"log"
 	"net/http"
 	"runtime/debug"
+
+	"github.com/joshbarros/golang-carflow-api/internal/apierror"
 )
 
 // RecoveryMiddleware handles panics in the HTTP handlers
@@ -15,9 +17,7 @@ func RecoveryMiddleware(next http.Handler) http.Handler {
 				log.Printf("PANIC: %v\n%s", err, debug.Stack())
 
 				// Return an internal server error
-				w.Header().Set("Content-Type", "application/json")
-				w.WriteHeader(http.StatusInternalServerError)
-				w.Write([]byte(`{"error":"Internal server error"}`))
+				apierror.Write(w, http.StatusInternalServerError, apierror.InternalError, "Internal server error")
 			}
 		}()
 
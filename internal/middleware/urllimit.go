@@ -0,0 +1,38 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/joshbarros/golang-carflow-api/internal/apierror"
+)
+
+// URLLengthMiddleware rejects requests whose URL is longer than maxURL bytes
+// (414) or that carry a query parameter value longer than maxParam bytes
+// (400). Lengths are checked on the raw URL before any parsing.
+func URLLengthMiddleware(maxURL, maxParam int) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if len(r.RequestURI) > maxURL {
+				apierror.Write(w, http.StatusRequestURITooLong, apierror.URITooLong, fmt.Sprintf("Request URL exceeds %d bytes", maxURL))
+				return
+			}
+
+			for _, pair := range strings.Split(r.URL.RawQuery, "&") {
+				key, value, _ := strings.Cut(pair, "=")
+				if len(value) > maxParam {
+					name, err := url.QueryUnescape(key)
+					if err != nil {
+						name = key
+					}
+					apierror.Write(w, http.StatusBadRequest, apierror.BadRequest, fmt.Sprintf("Query parameter %q exceeds %d bytes", name, maxParam))
+					return
+				}
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
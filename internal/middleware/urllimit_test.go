@@ -0,0 +1,35 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestURLLengthMiddleware(t *testing.T) {
+	handler := URLLengthMiddleware(100, 20)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	tests := []struct {
+		name   string
+		target string
+		want   int
+	}{
+		{name: "Short URL", target: "/cars?make=Toyota", want: http.StatusOK},
+		{name: "Long URL", target: "/cars?" + strings.Repeat("a=1&", 30), want: http.StatusRequestURITooLong},
+		{name: "Long parameter", target: "/cars?make=" + strings.Repeat("x", 21), want: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.target, nil))
+
+			if rec.Code != tt.want {
+				t.Errorf("status = %d, want %d", rec.Code, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,285 @@
+// Package openapi generates an OpenAPI 3.0 document from the routes
+// registered at startup, so the published spec can't drift from the code.
+package openapi
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"regexp"
+	"strings"
+
+	"github.com/joshbarros/golang-carflow-api/internal/apierror"
+)
+
+// Version is the OpenAPI version of generated documents
+const Version = "3.0.3"
+
+// Router wraps a ServeMux and records the pattern of every route
+// registered through it
+type Router struct {
+	mux      *http.ServeMux
+	patterns []string
+}
+
+// NewRouter creates a router that registers routes on mux
+func NewRouter(mux *http.ServeMux) *Router {
+	return &Router{mux: mux}
+}
+
+// Handle registers handler for pattern
+func (r *Router) Handle(pattern string, handler http.Handler) {
+	r.mux.Handle(pattern, handler)
+	r.patterns = append(r.patterns, pattern)
+}
+
+// HandleFunc registers handler for pattern
+func (r *Router) HandleFunc(pattern string, handler func(http.ResponseWriter, *http.Request)) {
+	r.mux.HandleFunc(pattern, handler)
+	r.patterns = append(r.patterns, pattern)
+}
+
+// Patterns returns the registered patterns in registration order
+func (r *Router) Patterns() []string {
+	return append([]string(nil), r.patterns...)
+}
+
+// Document is an OpenAPI document
+type Document struct {
+	OpenAPI    string                          `json:"openapi"`
+	Info       Info                            `json:"info"`
+	Paths      map[string]map[string]Operation `json:"paths"`
+	Components Components                      `json:"components"`
+}
+
+// Info describes the API
+type Info struct {
+	Title   string `json:"title"`
+	Version string `json:"version"`
+}
+
+// Components holds the reusable schemas referenced from operations
+type Components struct {
+	Schemas map[string]*Schema `json:"schemas"`
+}
+
+// Operation describes a single method on a path
+type Operation struct {
+	Summary     string              `json:"summary,omitempty"`
+	Parameters  []Parameter         `json:"parameters,omitempty"`
+	RequestBody *RequestBody        `json:"requestBody,omitempty"`
+	Responses   map[string]Response `json:"responses"`
+}
+
+// Parameter describes a path, query or header parameter
+type Parameter struct {
+	Name        string  `json:"name"`
+	In          string  `json:"in"`
+	Description string  `json:"description,omitempty"`
+	Required    bool    `json:"required,omitempty"`
+	Schema      *Schema `json:"schema"`
+}
+
+// RequestBody describes the payload an operation accepts
+type RequestBody struct {
+	Required bool                 `json:"required,omitempty"`
+	Content  map[string]MediaType `json:"content"`
+}
+
+// Response describes one response of an operation
+type Response struct {
+	Description string               `json:"description"`
+	Content     map[string]MediaType `json:"content,omitempty"`
+}
+
+// MediaType pairs a content type with the schema of its body
+type MediaType struct {
+	Schema *Schema `json:"schema"`
+}
+
+// Schema is the subset of JSON Schema used in generated documents
+type Schema struct {
+	Ref                  string             `json:"$ref,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Enum                 []string           `json:"enum,omitempty"`
+	Nullable             bool               `json:"nullable,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	OneOf                []*Schema          `json:"oneOf,omitempty"`
+}
+
+// Ref returns a schema referring to the named component schema
+func Ref(name string) *Schema {
+	return &Schema{Ref: "#/components/schemas/" + name}
+}
+
+// ArrayOf returns an array schema with the given item schema
+func ArrayOf(items *Schema) *Schema {
+	return &Schema{Type: "array", Items: items}
+}
+
+// QueryParam describes an optional query parameter of the given JSON type
+func QueryParam(name, typ, description string) Parameter {
+	return Parameter{Name: name, In: "query", Description: description, Schema: &Schema{Type: typ}}
+}
+
+// JSON returns content of type application/json with the given schema
+func JSON(schema *Schema) map[string]MediaType {
+	return map[string]MediaType{"application/json": {Schema: schema}}
+}
+
+// ErrorResponse describes an error response using the shared Error schema
+func ErrorResponse(description string) Response {
+	return Response{Description: description, Content: JSON(Ref("Error"))}
+}
+
+// errorSchema is the body of every JSON error response
+var errorSchema = &Schema{
+	Type: "object",
+	Properties: map[string]*Schema{
+		"error": {Type: "string"},
+		"code":  {Type: "string", Enum: errorCodes()},
+		"field": {Type: "string"},
+	},
+	Required: []string{"error", "code"},
+}
+
+// errorCodes returns the documented error codes as strings
+func errorCodes() []string {
+	codes := make([]string, len(apierror.Codes))
+	for i, code := range apierror.Codes {
+		codes[i] = string(code)
+	}
+	return codes
+}
+
+// Spec builds documents from a router's recorded routes, adding the
+// operation details and schemas that packages describe
+type Spec struct {
+	info       Info
+	router     *Router
+	operations map[string]Operation
+	schemas    map[string]reflect.Type
+	expansions map[string][]string
+}
+
+// NewSpec creates a spec for the routes recorded by router
+func NewSpec(title, version string, router *Router) *Spec {
+	return &Spec{
+		info:       Info{Title: title, Version: version},
+		router:     router,
+		operations: make(map[string]Operation),
+		schemas:    make(map[string]reflect.Type),
+		expansions: make(map[string][]string),
+	}
+}
+
+// Describe adds operation details keyed by route pattern, e.g. "GET /cars".
+// Routes without details are still listed with a generic response.
+func (s *Spec) Describe(operations map[string]Operation) {
+	for pattern, op := range operations {
+		s.operations[pattern] = op
+	}
+}
+
+// Expand documents the registered route pattern as the given patterns
+// instead, for a route that serves several paths ServeMux can't register
+// separately
+func (s *Spec) Expand(pattern string, documented ...string) {
+	s.expansions[pattern] = documented
+}
+
+// AddSchema registers the type of v as a named component schema. Other
+// schemas refer to it by name instead of repeating it.
+func (s *Spec) AddSchema(name string, v interface{}) {
+	s.schemas[name] = reflect.TypeOf(v)
+}
+
+// pathParam matches wildcards in route patterns such as {id} or {path...}
+var pathParam = regexp.MustCompile(`\{([^}.]+)(\.\.\.)?\}`)
+
+// Document generates the OpenAPI document for the routes registered so far
+func (s *Spec) Document() Document {
+	doc := Document{
+		OpenAPI: Version,
+		Info:    s.info,
+		Paths:   make(map[string]map[string]Operation),
+		Components: Components{
+			Schemas: map[string]*Schema{"Error": errorSchema},
+		},
+	}
+
+	for _, registered := range s.router.Patterns() {
+		patterns := []string{registered}
+		if documented, ok := s.expansions[registered]; ok {
+			patterns = documented
+		}
+
+		for _, pattern := range patterns {
+			method, path := splitPattern(pattern)
+
+			op, ok := s.operations[pattern]
+			if !ok {
+				op = Operation{Responses: map[string]Response{"200": {Description: "OK"}}}
+			}
+			op.Parameters = withPathParams(op.Parameters, path)
+
+			if doc.Paths[path] == nil {
+				doc.Paths[path] = make(map[string]Operation)
+			}
+			doc.Paths[path][strings.ToLower(method)] = op
+		}
+	}
+
+	names := make(map[reflect.Type]string, len(s.schemas))
+	for name, t := range s.schemas {
+		names[t] = name
+	}
+	for name, t := range s.schemas {
+		doc.Components.Schemas[name] = schemaFor(t, names, true)
+	}
+
+	return doc
+}
+
+// ServeHTTP serves the generated document as JSON
+func (s *Spec) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(s.Document())
+}
+
+// splitPattern separates a route pattern into its method and path. Patterns
+// without a method match every method and are documented as GET. Host
+// prefixes are dropped.
+func splitPattern(pattern string) (string, string) {
+	method, path := http.MethodGet, pattern
+	if i := strings.IndexByte(pattern, ' '); i >= 0 {
+		method, path = pattern[:i], strings.TrimSpace(pattern[i+1:])
+	}
+	if i := strings.IndexByte(path, '/'); i > 0 {
+		path = path[i:]
+	}
+	return method, pathParam.ReplaceAllString(path, "{$1}")
+}
+
+// withPathParams adds a required string parameter for every wildcard in
+// path that params doesn't already describe
+func withPathParams(params []Parameter, path string) []Parameter {
+	described := make(map[string]bool)
+	for _, p := range params {
+		if p.In == "path" {
+			described[p.Name] = true
+		}
+	}
+
+	var result []Parameter
+	for _, m := range pathParam.FindAllStringSubmatch(path, -1) {
+		if !described[m[1]] {
+			result = append(result, Parameter{Name: m[1], In: "path", Required: true, Schema: &Schema{Type: "string"}})
+		}
+	}
+	return append(result, params...)
+}
@@ -0,0 +1,139 @@
+package openapi
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+
+	"github.com/joshbarros/golang-carflow-api/internal/timestamp"
+)
+
+type item struct {
+	ID        string          `json:"id"`
+	Name      string          `json:"name,omitempty"`
+	Count     int             `json:"count"`
+	CreatedAt timestamp.Time  `json:"created_at"`
+	DeletedAt *timestamp.Time `json:"deleted_at,omitempty"`
+	Secret    string          `json:"-"`
+}
+
+type page struct {
+	list
+	Meta *string `json:"meta"`
+}
+
+type list struct {
+	Items []item `json:"items"`
+}
+
+func TestSpec_Document(t *testing.T) {
+	noop := func(w http.ResponseWriter, r *http.Request) {}
+
+	router := NewRouter(http.NewServeMux())
+	router.HandleFunc("GET /items", noop)
+	router.HandleFunc("GET /items/{id}", noop)
+	router.HandleFunc("DELETE /items/{id}", noop)
+	router.HandleFunc("GET /files/{path...}", noop)
+
+	spec := NewSpec("Test API", "1.0.0", router)
+	spec.AddSchema("Item", item{})
+	spec.AddSchema("Page", page{})
+	spec.Describe(map[string]Operation{
+		"GET /items": {
+			Summary:    "List items",
+			Parameters: []Parameter{QueryParam("page", "integer", "")},
+			Responses:  map[string]Response{"200": {Description: "Items", Content: JSON(Ref("Page"))}},
+		},
+	})
+
+	doc := spec.Document()
+
+	if got := doc.Paths["/items"]["get"].Summary; got != "List items" {
+		t.Errorf("GET /items summary = %q, want %q", got, "List items")
+	}
+	if _, ok := doc.Paths["/items/{id}"]["delete"].Responses["200"]; !ok {
+		t.Errorf("undescribed DELETE /items/{id} should get a default response")
+	}
+
+	pathParams := func(op Operation) []string {
+		var names []string
+		for _, p := range op.Parameters {
+			if p.In == "path" && p.Required {
+				names = append(names, p.Name)
+			}
+		}
+		return names
+	}
+	if got := pathParams(doc.Paths["/items/{id}"]["get"]); !slices.Equal(got, []string{"id"}) {
+		t.Errorf("GET /items/{id} path params = %v, want [id]", got)
+	}
+	if got := pathParams(doc.Paths["/files/{path}"]["get"]); !slices.Equal(got, []string{"path"}) {
+		t.Errorf("GET /files/{path} path params = %v, want [path]", got)
+	}
+
+	itemSchema := doc.Components.Schemas["Item"]
+	if !slices.Equal(itemSchema.Required, []string{"id", "count", "created_at"}) {
+		t.Errorf("Item required = %v, want [id count created_at]", itemSchema.Required)
+	}
+	if _, ok := itemSchema.Properties["Secret"]; ok {
+		t.Errorf("Item should not include fields tagged json:\"-\"")
+	}
+	if s := itemSchema.Properties["created_at"]; s.Type != "string" || s.Format != "date-time" {
+		t.Errorf("created_at schema = %+v, want date-time string", s)
+	}
+	if s := itemSchema.Properties["deleted_at"]; !s.Nullable {
+		t.Errorf("deleted_at schema = %+v, want nullable", s)
+	}
+
+	pageSchema := doc.Components.Schemas["Page"]
+	if s := pageSchema.Properties["items"]; s == nil || s.Items == nil || s.Items.Ref != "#/components/schemas/Item" {
+		t.Errorf("Page items schema = %+v, want array of Item refs", s)
+	}
+	if !slices.Equal(pageSchema.Required, []string{"items"}) {
+		t.Errorf("Page required = %v, want [items]", pageSchema.Required)
+	}
+}
+
+func TestSpec_Expand(t *testing.T) {
+	router := NewRouter(http.NewServeMux())
+	router.HandleFunc("GET /items/{id}/{sub}", func(w http.ResponseWriter, r *http.Request) {})
+
+	spec := NewSpec("Test API", "1.0.0", router)
+	spec.Expand("GET /items/{id}/{sub}", "GET /items/by-name/{name}", "GET /items/{id}/price")
+	doc := spec.Document()
+
+	if _, ok := doc.Paths["/items/{id}/{sub}"]; ok {
+		t.Errorf("spec documents the expanded pattern itself")
+	}
+	for _, path := range []string{"/items/by-name/{name}", "/items/{id}/price"} {
+		if _, ok := doc.Paths[path]["get"]; !ok {
+			t.Errorf("spec is missing GET %s", path)
+		}
+	}
+}
+
+func TestSpec_ServeHTTP(t *testing.T) {
+	mux := http.NewServeMux()
+	router := NewRouter(mux)
+	spec := NewSpec("Test API", "1.0.0", router)
+	router.Handle("GET /openapi.json", spec)
+
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/openapi.json", nil))
+
+	var doc Document
+	if err := json.NewDecoder(rec.Body).Decode(&doc); err != nil {
+		t.Fatalf("decoding spec: %v", err)
+	}
+	if doc.OpenAPI != Version || doc.Info.Title != "Test API" {
+		t.Errorf("spec = %+v, want OpenAPI %s for Test API", doc, Version)
+	}
+	if _, ok := doc.Paths["/openapi.json"]["get"]; !ok {
+		t.Errorf("spec should document its own route")
+	}
+	if _, ok := doc.Components.Schemas["Error"]; !ok {
+		t.Errorf("spec should always define the Error schema")
+	}
+}
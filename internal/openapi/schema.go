@@ -0,0 +1,95 @@
+package openapi
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/joshbarros/golang-carflow-api/internal/timestamp"
+)
+
+var (
+	timeType      = reflect.TypeOf(time.Time{})
+	timestampType = reflect.TypeOf(timestamp.Time{})
+	marshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+)
+
+// schemaFor derives a schema from a Go type the way encoding/json would
+// serialize it. Types registered in names are referenced rather than
+// inlined, except at the top level where the named schema is defined.
+func schemaFor(t reflect.Type, names map[reflect.Type]string, top bool) *Schema {
+	if t.Kind() == reflect.Pointer {
+		schema := schemaFor(t.Elem(), names, top)
+		if schema.Ref == "" {
+			schema.Nullable = true
+		}
+		return schema
+	}
+
+	if name, ok := names[t]; ok && !top {
+		return Ref(name)
+	}
+
+	switch {
+	case t == timeType || t == timestampType:
+		return &Schema{Type: "string", Format: "date-time"}
+	case t.Implements(marshalerType) || reflect.PointerTo(t).Implements(marshalerType):
+		// Custom encodings can't be inspected; leave the schema open
+		return &Schema{}
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &Schema{Type: "integer"}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: "number"}
+	case reflect.Slice, reflect.Array:
+		return ArrayOf(schemaFor(t.Elem(), names, false))
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: schemaFor(t.Elem(), names, false)}
+	case reflect.Struct:
+		schema := &Schema{Type: "object", Properties: make(map[string]*Schema)}
+		addFields(schema, t, names)
+		return schema
+	}
+
+	return &Schema{}
+}
+
+// addFields adds the JSON-encoded fields of struct type t to schema,
+// flattening embedded structs as encoding/json does. Fields that are
+// always present, those without omitempty that aren't pointers, are listed
+// as required.
+func addFields(schema *Schema, t reflect.Type, names map[reflect.Type]string) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+
+		if field.Anonymous && name == "" && field.Type.Kind() == reflect.Struct {
+			addFields(schema, field.Type, names)
+			continue
+		}
+		if !field.IsExported() {
+			continue
+		}
+
+		if name == "" {
+			name = field.Name
+		}
+		schema.Properties[name] = schemaFor(field.Type, names, false)
+		if !strings.Contains(opts, "omitempty") && field.Type.Kind() != reflect.Pointer {
+			schema.Required = append(schema.Required, name)
+		}
+	}
+}
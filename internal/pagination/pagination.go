@@ -0,0 +1,140 @@
+package pagination
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+const (
+	// DefaultPageSize is the page size used when none is requested
+	DefaultPageSize = 10
+	// MaxPageSize is the largest page size a client may request
+	MaxPageSize = 100
+)
+
+var (
+	// ErrInvalidPage is returned when page isn't a positive integer
+	ErrInvalidPage = errors.New("invalid page parameter")
+	// ErrInvalidPageSize is returned when page_size isn't an integer
+	// between 1 and MaxPageSize
+	ErrInvalidPageSize = fmt.Errorf("invalid page_size parameter (must be between 1 and %d)", MaxPageSize)
+)
+
+// Params contains options for paginating results
+type Params struct {
+	Page     int
+	PageSize int
+}
+
+// Result represents a paginated result set
+type Result[T any] struct {
+	Data       []T `json:"data"`
+	TotalItems int `json:"total_items"`
+	TotalPages int `json:"total_pages"`
+	Page       int `json:"page"`
+	PageSize   int `json:"page_size"`
+}
+
+// FromQuery parses the page and page_size query parameters, applying the
+// defaults when they are absent
+func FromQuery(query url.Values) (Params, error) {
+	params := Params{
+		Page:     1,
+		PageSize: DefaultPageSize,
+	}
+
+	// Parse page parameter
+	if pageStr := query.Get("page"); pageStr != "" {
+		page, err := strconv.Atoi(pageStr)
+		if err != nil || page < 1 {
+			return Params{}, ErrInvalidPage
+		}
+		params.Page = page
+	}
+
+	// Parse page_size parameter
+	if pageSizeStr := query.Get("page_size"); pageSizeStr != "" {
+		pageSize, err := strconv.Atoi(pageSizeStr)
+		if err != nil || pageSize < 1 || pageSize > MaxPageSize {
+			return Params{}, ErrInvalidPageSize
+		}
+		params.PageSize = pageSize
+	}
+
+	return params, nil
+}
+
+// Paginate returns the requested page of items. The page is clamped to the
+// available range so an out-of-range page returns the last one.
+func Paginate[T any](items []T, params Params) Result[T] {
+	totalItems := len(items)
+
+	// Default pagination values if not set
+	if params.Page < 1 {
+		params.Page = 1
+	}
+
+	if params.PageSize < 1 {
+		params.PageSize = DefaultPageSize
+	}
+
+	// Calculate total pages
+	totalPages := (totalItems + params.PageSize - 1) / params.PageSize
+	if totalPages == 0 {
+		totalPages = 1
+	}
+
+	// Ensure page is within bounds
+	if params.Page > totalPages {
+		params.Page = totalPages
+	}
+
+	// Calculate start and end indices
+	startIndex := (params.Page - 1) * params.PageSize
+	endIndex := startIndex + params.PageSize
+
+	// Ensure end index doesn't exceed array bounds
+	if endIndex > totalItems {
+		endIndex = totalItems
+	}
+
+	// Get the slice of items for the current page
+	data := []T{}
+	if startIndex < totalItems {
+		data = items[startIndex:endIndex]
+	}
+
+	return Result[T]{
+		Data:       data,
+		TotalItems: totalItems,
+		TotalPages: totalPages,
+		Page:       params.Page,
+		PageSize:   params.PageSize,
+	}
+}
+
+// LinkHeader builds an RFC 5988 Link header value with first, prev, next and
+// last relations for the given request URL, preserving its other query
+// parameters
+func LinkHeader(u *url.URL, page, totalPages int) string {
+	link := func(p int, rel string) string {
+		query := u.Query()
+		query.Set("page", strconv.Itoa(p))
+		target := url.URL{Path: u.Path, RawQuery: query.Encode()}
+		return fmt.Sprintf("<%s>; rel=\"%s\"", target.String(), rel)
+	}
+
+	links := []string{link(1, "first")}
+	if page > 1 {
+		links = append(links, link(page-1, "prev"))
+	}
+	if page < totalPages {
+		links = append(links, link(page+1, "next"))
+	}
+	links = append(links, link(totalPages, "last"))
+
+	return strings.Join(links, ", ")
+}
@@ -0,0 +1,80 @@
+package pagination
+
+import (
+	"errors"
+	"net/url"
+	"testing"
+)
+
+func TestFromQuery(t *testing.T) {
+	tests := []struct {
+		name    string
+		query   string
+		want    Params
+		wantErr error
+	}{
+		{name: "Defaults", query: "", want: Params{Page: 1, PageSize: DefaultPageSize}},
+		{name: "Explicit values", query: "page=3&page_size=25", want: Params{Page: 3, PageSize: 25}},
+		{name: "Invalid page", query: "page=zero", wantErr: ErrInvalidPage},
+		{name: "Page below one", query: "page=0", wantErr: ErrInvalidPage},
+		{name: "Page size too large", query: "page_size=101", wantErr: ErrInvalidPageSize},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query, _ := url.ParseQuery(tt.query)
+			got, err := FromQuery(query)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("FromQuery() error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr == nil && got != tt.want {
+				t.Errorf("FromQuery() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPaginate(t *testing.T) {
+	items := []int{1, 2, 3, 4, 5}
+
+	result := Paginate(items, Params{Page: 2, PageSize: 2})
+	if len(result.Data) != 2 || result.Data[0] != 3 || result.Data[1] != 4 {
+		t.Errorf("Paginate() data = %v, want [3 4]", result.Data)
+	}
+	if result.TotalItems != 5 || result.TotalPages != 3 {
+		t.Errorf("Paginate() totals = %d items, %d pages, want 5 items, 3 pages", result.TotalItems, result.TotalPages)
+	}
+
+	// Out-of-range pages are clamped to the last page
+	result = Paginate(items, Params{Page: 10, PageSize: 2})
+	if result.Page != 3 || len(result.Data) != 1 {
+		t.Errorf("Paginate() = page %d with %v, want page 3 with [5]", result.Page, result.Data)
+	}
+
+	// An empty set still reports a single empty page
+	empty := Paginate([]int{}, Params{Page: 1, PageSize: 10})
+	if empty.TotalPages != 1 || empty.Data == nil || len(empty.Data) != 0 {
+		t.Errorf("Paginate() on empty input = %+v, want one empty page", empty)
+	}
+}
+
+func TestLinkHeader(t *testing.T) {
+	u, _ := url.Parse("/cars?make=Toyota&page=2&page_size=5")
+
+	got := LinkHeader(u, 2, 3)
+	want := `</cars?make=Toyota&page=1&page_size=5>; rel="first", ` +
+		`</cars?make=Toyota&page=1&page_size=5>; rel="prev", ` +
+		`</cars?make=Toyota&page=3&page_size=5>; rel="next", ` +
+		`</cars?make=Toyota&page=3&page_size=5>; rel="last"`
+	if got != want {
+		t.Errorf("LinkHeader() = %s, want %s", got, want)
+	}
+
+	// A single page has no prev or next relations
+	got = LinkHeader(u, 1, 1)
+	want = `</cars?make=Toyota&page=1&page_size=5>; rel="first", ` +
+		`</cars?make=Toyota&page=1&page_size=5>; rel="last"`
+	if got != want {
+		t.Errorf("LinkHeader() = %s, want %s", got, want)
+	}
+}
@@ -0,0 +1,64 @@
+package pagination
+
+import (
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+)
+
+// SortOption sorts results by one field
+type SortOption struct {
+	Field string
+	Order string // "asc" or "desc"
+}
+
+// SortFromQuery parses the comma-separated sort query parameter, e.g.
+// "make,-year". A "-" prefix sorts that field descending; otherwise the
+// order parameter applies, falling back to the field's default in defaults
+// and then to ascending. Every field is validated against allowed.
+func SortFromQuery(query url.Values, allowed []string, defaults map[string]string) ([]SortOption, error) {
+	sortParam := query.Get("sort")
+	if sortParam == "" {
+		return nil, nil
+	}
+
+	// Check if sort order is specified
+	order := strings.ToLower(query.Get("order"))
+	switch order {
+	case "", "asc", "desc":
+	default:
+		return nil, fmt.Errorf("invalid order %q (allowed: asc, desc)", order)
+	}
+
+	var opts []SortOption
+	seen := make(map[string]bool)
+	for _, field := range strings.Split(sortParam, ",") {
+		field = strings.TrimSpace(field)
+
+		fieldOrder := order
+		if strings.HasPrefix(field, "-") {
+			fieldOrder = "desc"
+			field = field[1:]
+		}
+
+		if !slices.Contains(allowed, field) {
+			return nil, fmt.Errorf("invalid sort field %q (allowed: %s)", field, strings.Join(allowed, ", "))
+		}
+		if seen[field] {
+			return nil, fmt.Errorf("duplicate sort field %q", field)
+		}
+		seen[field] = true
+
+		if fieldOrder == "" {
+			fieldOrder = "asc"
+			if defaultOrder, ok := defaults[field]; ok {
+				fieldOrder = defaultOrder
+			}
+		}
+
+		opts = append(opts, SortOption{Field: field, Order: fieldOrder})
+	}
+
+	return opts, nil
+}
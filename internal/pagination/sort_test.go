@@ -0,0 +1,60 @@
+package pagination
+
+import (
+	"net/url"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestSortFromQuery(t *testing.T) {
+	tests := []struct {
+		name    string
+		sort    string
+		order   string
+		want    []SortOption
+		wantErr string
+	}{
+		{name: "No sort", sort: ""},
+		{name: "Ascending", sort: "make", want: []SortOption{{"make", "asc"}}},
+		{name: "Descending", sort: "-year", want: []SortOption{{"year", "desc"}}},
+		{name: "Field default order", sort: "year", want: []SortOption{{"year", "desc"}}},
+		{name: "Explicit order overrides default", sort: "year", order: "asc", want: []SortOption{{"year", "asc"}}},
+		{name: "Multiple fields", sort: "make,-year", want: []SortOption{{"make", "asc"}, {"year", "desc"}}},
+		{name: "Multiple fields with spaces", sort: "color, -id", want: []SortOption{{"color", "asc"}, {"id", "desc"}}},
+		{name: "Invalid order", sort: "make", order: "up", wantErr: "allowed: asc, desc"},
+		{name: "Unknown field", sort: "price", wantErr: "allowed: id, make"},
+		{name: "Unknown field later in list", sort: "make,-price", wantErr: `"price"`},
+		{name: "Empty field in list", sort: "make,,year", wantErr: "invalid sort field"},
+		{name: "Duplicate field", sort: "year,-year", wantErr: "duplicate sort field"},
+	}
+
+	allowed := []string{"id", "make", "model", "year", "color"}
+	defaults := map[string]string{"year": "desc"}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query := url.Values{}
+			if tt.sort != "" {
+				query.Set("sort", tt.sort)
+			}
+			if tt.order != "" {
+				query.Set("order", tt.order)
+			}
+
+			opts, err := SortFromQuery(query, allowed, defaults)
+			if (err != nil) != (tt.wantErr != "") {
+				t.Fatalf("SortFromQuery() error = %v, wantErr %q", err, tt.wantErr)
+			}
+			if err != nil {
+				if !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("SortFromQuery() error = %v, want it to contain %q", err, tt.wantErr)
+				}
+				return
+			}
+			if !slices.Equal(opts, tt.want) {
+				t.Errorf("SortFromQuery() = %+v, want %+v", opts, tt.want)
+			}
+		})
+	}
+}
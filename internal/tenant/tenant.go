@@ -0,0 +1,97 @@
+// Package tenant carries the ID of the tenant a request acts on.
+package tenant
+
+import (
+	"context"
+	"net/http"
+	"regexp"
+
+	"github.com/joshbarros/golang-carflow-api/internal/apierror"
+)
+
+const (
+	// Header is the request header naming the tenant
+	Header = "X-Tenant-ID"
+	// DefaultID is used when a request doesn't name a tenant
+	DefaultID = "default"
+)
+
+// Header modes decide which requests may name a tenant in the X-Tenant-ID
+// header. Requests that don't name one always use DefaultID.
+const (
+	// ModeTrust honors any well-formed header
+	ModeTrust = "trust"
+	// ModeAdminOnly honors a header naming a tenant other than DefaultID
+	// only on requests carrying the admin token
+	ModeAdminOnly = "admin-only"
+	// ModeFixed serves every request as DefaultID and rejects a header
+	// naming another tenant
+	ModeFixed = "fixed"
+)
+
+// Modes lists the supported header modes
+var Modes = []string{ModeTrust, ModeAdminOnly, ModeFixed}
+
+// idPattern restricts tenant IDs to a safe set of characters
+var idPattern = regexp.MustCompile(`^[a-zA-Z0-9_-]{1,64}$`)
+
+type contextKey struct{}
+
+// WithID returns a copy of ctx carrying the tenant ID
+func WithID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, contextKey{}, id)
+}
+
+// FromContext returns the tenant ID carried by ctx, or DefaultID if none
+func FromContext(ctx context.Context) string {
+	if id, ok := ctx.Value(contextKey{}).(string); ok && id != "" {
+		return id
+	}
+	return DefaultID
+}
+
+// Middleware reads the tenant ID from the X-Tenant-ID header into the request
+// context, falling back to DefaultID. Malformed IDs are rejected with 400.
+// Any well-formed ID is trusted; use ModeMiddleware to restrict the header.
+func Middleware(next http.Handler) http.Handler {
+	return ModeMiddleware(ModeTrust, nil)(next)
+}
+
+// ModeMiddleware works like Middleware but only honors a header naming a
+// tenant other than DefaultID as the given mode allows, rejecting it with
+// 403 otherwise. isAdmin identifies admin requests for ModeAdminOnly and may
+// be nil when no request is an admin. Unknown modes behave like ModeFixed.
+func ModeMiddleware(mode string, isAdmin func(*http.Request) bool) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			id := r.Header.Get(Header)
+			if id == "" {
+				id = DefaultID
+			}
+
+			if !idPattern.MatchString(id) {
+				apierror.Write(w, http.StatusBadRequest, apierror.InvalidTenant, "Invalid "+Header+" header")
+				return
+			}
+
+			if id != DefaultID && !allowed(mode, isAdmin, r) {
+				apierror.Write(w, http.StatusForbidden, apierror.Forbidden, "Not allowed to act on tenant "+id)
+				return
+			}
+
+			next.ServeHTTP(w, r.WithContext(WithID(r.Context(), id)))
+		})
+	}
+}
+
+// allowed reports whether mode lets r act on a tenant other than DefaultID
+func allowed(mode string, isAdmin func(*http.Request) bool, r *http.Request) bool {
+	switch mode {
+	case ModeTrust:
+		return true
+	case ModeAdminOnly:
+		return isAdmin != nil && isAdmin(r)
+	default:
+		return false
+	}
+}
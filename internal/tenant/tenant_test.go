@@ -0,0 +1,118 @@
+package tenant
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestMiddleware(t *testing.T) {
+	var got string
+	handler := Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = FromContext(r.Context())
+	}))
+
+	tests := []struct {
+		name   string
+		header string
+		want   string
+		status int
+	}{
+		{name: "Default tenant", header: "", want: DefaultID, status: http.StatusOK},
+		{name: "Named tenant", header: "acme-motors", want: "acme-motors", status: http.StatusOK},
+		{name: "Invalid tenant", header: "acme motors!", status: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got = ""
+			req := httptest.NewRequest(http.MethodGet, "/cars", nil)
+			if tt.header != "" {
+				req.Header.Set(Header, tt.header)
+			}
+			rec := httptest.NewRecorder()
+
+			handler.ServeHTTP(rec, req)
+
+			if rec.Code != tt.status {
+				t.Errorf("status = %d, want %d", rec.Code, tt.status)
+			}
+			if got != tt.want {
+				t.Errorf("tenant = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestModeMiddleware(t *testing.T) {
+	isAdmin := func(r *http.Request) bool {
+		return r.Header.Get("Authorization") == "Bearer secret"
+	}
+
+	tests := []struct {
+		name   string
+		mode   string
+		header string
+		admin  bool
+		want   string
+		status int
+	}{
+		{name: "Trust named tenant", mode: ModeTrust, header: "acme-motors", want: "acme-motors", status: http.StatusOK},
+		{name: "Admin-only default tenant", mode: ModeAdminOnly, want: DefaultID, status: http.StatusOK},
+		{name: "Admin-only explicit default tenant", mode: ModeAdminOnly, header: DefaultID, want: DefaultID, status: http.StatusOK},
+		{name: "Admin-only named tenant as admin", mode: ModeAdminOnly, header: "acme-motors", admin: true, want: "acme-motors", status: http.StatusOK},
+		{name: "Admin-only named tenant without admin", mode: ModeAdminOnly, header: "acme-motors", status: http.StatusForbidden},
+		{name: "Admin-only invalid tenant", mode: ModeAdminOnly, header: "acme motors!", admin: true, status: http.StatusBadRequest},
+		{name: "Fixed default tenant", mode: ModeFixed, want: DefaultID, status: http.StatusOK},
+		{name: "Fixed named tenant as admin", mode: ModeFixed, header: "acme-motors", admin: true, status: http.StatusForbidden},
+		{name: "Unknown mode named tenant", mode: "open", header: "acme-motors", status: http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got string
+			handler := ModeMiddleware(tt.mode, isAdmin)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				got = FromContext(r.Context())
+			}))
+
+			req := httptest.NewRequest(http.MethodGet, "/cars", nil)
+			if tt.header != "" {
+				req.Header.Set(Header, tt.header)
+			}
+			if tt.admin {
+				req.Header.Set("Authorization", "Bearer secret")
+			}
+			rec := httptest.NewRecorder()
+
+			handler.ServeHTTP(rec, req)
+
+			if rec.Code != tt.status {
+				t.Errorf("status = %d, want %d", rec.Code, tt.status)
+			}
+			if got != tt.want {
+				t.Errorf("tenant = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestModeMiddleware_AdminOnlyWithoutCheck(t *testing.T) {
+	handler := ModeMiddleware(ModeAdminOnly, nil)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	req := httptest.NewRequest(http.MethodGet, "/cars", nil)
+	req.Header.Set(Header, "acme-motors")
+	rec := httptest.NewRecorder()
+
+	handler.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusForbidden {
+		t.Errorf("status = %d, want %d", rec.Code, http.StatusForbidden)
+	}
+}
+
+func TestFromContext_Default(t *testing.T) {
+	if got := FromContext(context.Background()); got != DefaultID {
+		t.Errorf("FromContext() = %q, want %q", got, DefaultID)
+	}
+}
@@ -0,0 +1,52 @@
+// Package timestamp provides the time format used in all API responses:
+// RFC 3339 in UTC with second precision, e.g. "2024-05-01T12:30:00Z".
+package timestamp
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Format is the layout used to serialize timestamps
+const Format = time.RFC3339
+
+// Time wraps time.Time so it serializes in the API timestamp format
+type Time struct {
+	time.Time
+}
+
+// New wraps t as an API timestamp
+func New(t time.Time) Time {
+	return Time{Time: t}
+}
+
+// Now returns the current time as an API timestamp
+func Now() Time {
+	return New(time.Now())
+}
+
+// String formats the time in the API timestamp format
+func (t Time) String() string {
+	return t.UTC().Format(Format)
+}
+
+// MarshalJSON encodes the time in the API timestamp format
+func (t Time) MarshalJSON() ([]byte, error) {
+	return json.Marshal(t.String())
+}
+
+// UnmarshalJSON decodes a time in the API timestamp format
+func (t *Time) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+
+	parsed, err := time.Parse(Format, s)
+	if err != nil {
+		return err
+	}
+
+	t.Time = parsed
+	return nil
+}
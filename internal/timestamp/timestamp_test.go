@@ -0,0 +1,29 @@
+package timestamp
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestTime_MarshalJSON(t *testing.T) {
+	loc := time.FixedZone("UTC-3", -3*60*60)
+	ts := New(time.Date(2024, 5, 1, 9, 30, 15, 123456789, loc))
+
+	data, err := json.Marshal(ts)
+	if err != nil {
+		t.Fatalf("MarshalJSON() error = %v", err)
+	}
+
+	if got, want := string(data), `"2024-05-01T12:30:15Z"`; got != want {
+		t.Errorf("MarshalJSON() = %s, want %s", got, want)
+	}
+
+	var decoded Time
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("UnmarshalJSON() error = %v", err)
+	}
+	if !decoded.Equal(ts.Truncate(time.Second)) {
+		t.Errorf("UnmarshalJSON() = %v, want %v", decoded, ts.Truncate(time.Second))
+	}
+}
@@ -13,6 +13,7 @@ import (
 	"github.com/joshbarros/golang-carflow-api/internal/health"
 	"github.com/joshbarros/golang-carflow-api/internal/metrics"
 	"github.com/joshbarros/golang-carflow-api/internal/middleware"
+	"github.com/joshbarros/golang-carflow-api/internal/tenant"
 )
 
 // setupBenchmarkServer creates a server for benchmarking
@@ -40,7 +41,7 @@ func setupBenchmarkServer() *httptest.Server {
 	handler := metrics.Middleware(metricsTracker)(
 		middleware.LoggingMiddleware(
 			middleware.RecoveryMiddleware(
-				mux,
+				tenant.Middleware(mux),
 			),
 		),
 	)
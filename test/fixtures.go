@@ -2,6 +2,7 @@ package test
 
 import (
 	"github.com/joshbarros/golang-carflow-api/internal/car"
+	"github.com/joshbarros/golang-carflow-api/internal/tenant"
 )
 
 // TestCars contains sample cars for testing
@@ -43,9 +44,10 @@ var TestCars = []car.Car{
 	},
 }
 
-// LoadFixtures populates a repository with test cars
+// LoadFixtures populates a repository with test cars owned by the default tenant
 func LoadFixtures(repo *car.InMemoryRepository) {
 	for _, c := range TestCars {
+		c.TenantID = tenant.DefaultID
 		repo.Create(c)
 	}
 }
@@ -14,6 +14,7 @@ import (
 	"github.com/joshbarros/golang-carflow-api/internal/health"
 	"github.com/joshbarros/golang-carflow-api/internal/metrics"
 	"github.com/joshbarros/golang-carflow-api/internal/middleware"
+	"github.com/joshbarros/golang-carflow-api/internal/tenant"
 )
 
 func setupTestServer() *httptest.Server {
@@ -28,7 +29,7 @@ func setupTestServer() *httptest.Server {
 	healthHandler := health.NewHandler()
 
 	// Add some sample data
-	carService.CreateCar(car.Car{ID: "test1", Make: "Toyota", Model: "Corolla", Year: 2020, Color: "blue"})
+	carService.CreateCar(car.Car{ID: "test1", TenantID: tenant.DefaultID, Make: "Toyota", Model: "Corolla", Year: 2020, Color: "blue"})
 
 	// Create server
 	mux := http.NewServeMux()
@@ -41,7 +42,7 @@ func setupTestServer() *httptest.Server {
 	handler := metrics.Middleware(metricsTracker)(
 		middleware.LoggingMiddleware(
 			middleware.RecoveryMiddleware(
-				mux,
+				tenant.Middleware(mux),
 			),
 		),
 	)
@@ -280,6 +281,117 @@ func TestCarsEndpoints(t *testing.T) {
 	})
 }
 
+func TestCarsAppliedMeta(t *testing.T) {
+	server := setupTestServer()
+	defer server.Close()
+
+	resp, err := http.Get(fmt.Sprintf("%s/cars?make=toyota&sort=-year&page=5&debug=true", server.URL))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	defer resp.Body.Close()
+
+	var result car.PagedResult
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+
+	if result.Meta == nil {
+		t.Fatal("Expected meta to be present when debug=true")
+	}
+
+	applied := result.Meta.Applied
+	if applied.Make != "toyota" || applied.Sort != "year" || applied.Order != "desc" {
+		t.Errorf("Unexpected applied options: %+v", applied)
+	}
+
+	// The requested page is clamped to the last available page
+	if applied.Page != 1 {
+		t.Errorf("Expected applied page 1, got %d", applied.Page)
+	}
+}
+
+func TestCreateCarIfNoneMatch(t *testing.T) {
+	server := setupTestServer()
+	defer server.Close()
+
+	createWithPrecondition := func(id string) *http.Response {
+		payload, _ := json.Marshal(car.Car{ID: id, Make: "Honda", Model: "Civic", Year: 2021, Color: "red"})
+		req, err := http.NewRequest(http.MethodPost, fmt.Sprintf("%s/cars", server.URL), bytes.NewBuffer(payload))
+		if err != nil {
+			t.Fatalf("Failed to create request: %v", err)
+		}
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("If-None-Match", "*")
+
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		return resp
+	}
+
+	resp := createWithPrecondition("if-none-match-1")
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusCreated {
+		t.Errorf("Expected status 201 for a new car, got %d", resp.StatusCode)
+	}
+
+	// test1 is created by setupTestServer
+	resp = createWithPrecondition("test1")
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusPreconditionFailed {
+		t.Errorf("Expected status 412 for an existing car, got %d", resp.StatusCode)
+	}
+}
+
+func TestCarVINAndValuationRoutes(t *testing.T) {
+	server := setupTestServer()
+	defer server.Close()
+
+	const vin = "1HGCM82633A004352"
+	payload, _ := json.Marshal(car.Car{ID: "vin-car", Make: "Honda", Model: "Accord", Year: 2003, VIN: vin})
+	resp, err := http.Post(fmt.Sprintf("%s/cars", server.URL), "application/json", bytes.NewBuffer(payload))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusCreated {
+		t.Fatalf("Expected status 201, got %d", resp.StatusCode)
+	}
+
+	resp, err = http.Get(fmt.Sprintf("%s/cars/vin/%s", server.URL, vin))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	var found car.Car
+	json.NewDecoder(resp.Body).Decode(&found)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || found.ID != "vin-car" {
+		t.Errorf("GET /cars/vin/%s = %d with car %q, want 200 with vin-car", vin, resp.StatusCode, found.ID)
+	}
+
+	resp, err = http.Get(fmt.Sprintf("%s/cars/vin-car/valuation", server.URL))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	var valuation car.Valuation
+	json.NewDecoder(resp.Body).Decode(&valuation)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || valuation.CarID != "vin-car" {
+		t.Errorf("GET /cars/vin-car/valuation = %d with car %q, want 200 with vin-car", resp.StatusCode, valuation.CarID)
+	}
+
+	resp, err = http.Get(fmt.Sprintf("%s/cars/vin-car/history", server.URL))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("GET /cars/vin-car/history = %d, want 404", resp.StatusCode)
+	}
+}
+
 func TestMain(m *testing.M) {
 	// Setup
 	os.Exit(m.Run())